@@ -0,0 +1,8 @@
+Feature: Database Automatic Cleanup
+
+  Scenario: Tables are cleaned before scenario
+    Then no rows are available in table "my_table" of database "my_db"
+
+  @keep-db
+  Scenario: Tables are kept
+    Then no rows are available in table "my_table" of database "my_db"
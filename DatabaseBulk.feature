@@ -0,0 +1,15 @@
+Feature: Database Bulk Assertion
+
+  Scenario: Assert rows with batched queries
+    Then only these rows are available in table "my_table" of database "my_db"
+      | id   | foo   | meta          |
+      | $id1 | foo-1 | {"key":"bar"} |
+      | 2    | foo-2 | NULL          |
+
+    And these rows are available in table "my_table" of database "my_db"
+      | id   | foo   |
+      | $id1 | foo-1 |
+
+    And these rows are available in table "my_table" of database "my_db"
+      | id   | foo   | meta          |
+      | $id1 | foo-1 | {"key":"baz"} |
@@ -0,0 +1,10 @@
+Feature: Database Chunked Insert
+
+  Scenario: Rows are inserted in chunks
+    Given these rows are stored in table "my_table" of database "my_db"
+      | id | foo   |
+      | 1  | foo-1 |
+      | 2  | foo-2 |
+      | 3  | foo-3 |
+      | 4  | foo-4 |
+      | 5  | foo-5 |
@@ -0,0 +1,4 @@
+Feature: Database Cleanup
+
+  Scenario: Clean all tables
+    Given there are no rows in any table of database "my_db"
@@ -0,0 +1,22 @@
+Feature: Database Concurrent Scenarios
+
+  Scenario: First scenario
+    Given these rows are available in table "my_table" of database "my_db"
+      | id  | foo  |
+      | $id | $foo |
+
+    Then there is 1 row in table "my_table" of database "my_db" where "id" is "$id"
+
+  Scenario: Second scenario
+    Given these rows are available in table "my_table" of database "my_db"
+      | id  | foo   |
+      | $id | foo-2 |
+
+    Then there is 1 row in table "my_table" of database "my_db" where "id" is "$id"
+
+  Scenario: Third scenario
+    Given these rows are available in table "my_table" of database "my_db"
+      | id  | foo   |
+      | $id | foo-3 |
+
+    Then there is 1 row in table "my_table" of database "my_db" where "id" is "$id"
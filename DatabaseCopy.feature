@@ -0,0 +1,7 @@
+Feature: Database Copy
+
+  Scenario: Rows from file are copied
+    Given rows from this file are stored in table "my_table" of database "my_db"
+    """
+    _testdata/rows.csv
+    """
@@ -0,0 +1,7 @@
+Feature: Database Row Count
+
+  Scenario: Successful Count
+    Then there are 3 rows in table "my_table" of database "my_db"
+    And there are 2 rows in table "my_table" of database "my_db" where "foo" is "foo-1"
+    And there is 1 row in table "my_table" of database "my_db" where "deleted_at" is "NULL"
+    And there are 0 rows in table "my_another_table" of database "my_db"
@@ -0,0 +1,7 @@
+Feature: Database Empty Object
+
+  Scenario: Rows with empty object are rejected
+    Given rows from this file are stored in table "my_table" of database "my_db"
+    """
+    _testdata/empty_object.json
+    """
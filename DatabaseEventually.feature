@@ -0,0 +1,15 @@
+Feature: Database Eventual Query
+
+  Scenario: Rows Become Available
+    Then eventually only these rows are available in table "my_table" of database "my_db"
+      | id   | foo   |
+      | $id1 | foo-1 |
+
+    And these rows are available in table "my_table" of database "my_db"
+      | id   | foo   |
+      | $id1 | foo-1 |
+
+  Scenario: Rows Do Not Become Available
+    Then eventually these rows are available in table "my_table" of database "my_db" within 10ms
+      | id | foo   |
+      | 2  | foo-2 |
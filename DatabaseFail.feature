@@ -4,3 +4,9 @@ Feature: Database Query
     Then only these rows are available in table "my_table" of database "my_db":
       | id | foo   | bar | created_at           | deleted_at |
       | 1  | foo-1 | abc | 2021-01-01T00:00:00Z | NULL       |
+
+  Scenario: Failing Ordered Query
+    Then only these rows are available in table "my_table" of database "my_db" ordered by "id":
+      | id | bar     |
+      | 2  | bar-122 |
+      | 1  | bar-1   |
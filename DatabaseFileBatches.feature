@@ -0,0 +1,12 @@
+Feature: Database File Batches
+
+  Scenario: Rows from file are processed in batches
+    Given rows from this file are stored in table "my_table" of database "my_db"
+    """
+    _testdata/rows.csv
+    """
+
+    Then only rows from this file are available in table "my_table" of database "my_db"
+    """
+    _testdata/rows.csv
+    """
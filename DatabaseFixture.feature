@@ -0,0 +1,8 @@
+Feature: Database Fixture
+
+  Scenario: Rows of multiple tables are loaded from fixture
+    Given fixture "_testdata/checkout.yaml" is loaded into database "my_db"
+
+    Then these rows are available in table "order_items" of database "my_db":
+      | id | order_id | sku   |
+      | 1  | $order   | sku-1 |
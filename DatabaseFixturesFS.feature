@@ -0,0 +1,13 @@
+Feature: Database Fixtures File System
+
+  Scenario: Files are read from fixtures file system with interpolated paths
+    Given these rows are stored in table "my_table" of database "my_db":
+      | id  | foo   |
+      | $id | foo-1 |
+
+    And the SQL file "update-$id.sql" is applied to database "my_db"
+
+    Then rows from this file are available in table "my_table" of database "my_db"
+    """
+    rows-$id.csv
+    """
@@ -0,0 +1,17 @@
+Feature: Database File Formats
+
+  Scenario: Rows are loaded from JSON, JSON Lines and YAML files
+    Then rows from this file are available in table "my_table" of database "my_db"
+    """
+    _testdata/rows.json
+    """
+
+    And rows from this file are available in table "my_table" of database "my_db"
+    """
+    _testdata/rows.jsonl
+    """
+
+    And rows from this file are available in table "my_table" of database "my_db"
+    """
+    _testdata/rows.yaml
+    """
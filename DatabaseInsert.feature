@@ -0,0 +1,21 @@
+Feature: Database Insert
+
+  Scenario: Generated Keys
+    Given these rows are stored in table "my_table" of database "my_db"
+      | id   | foo   |
+      | $id1 | foo-1 |
+      | 3    | foo-3 |
+      | $id2 | foo-2 |
+
+    And these rows are stored in table "my_another_table" of database "my_db"
+      | id   | parent_id | foo   |
+      | 1    | $id1      | bar-1 |
+      | 2    | $id2      | bar-2 |
+
+    And these rows are stored in table "my_table" of database "my_legacy_db"
+      | id   | foo   |
+      | $id3 | foo-4 |
+
+    And these rows are stored in table "my_another_table" of database "my_legacy_db"
+      | id   | parent_id | foo   |
+      | 3    | $id3      | bar-3 |
@@ -0,0 +1,24 @@
+Feature: Database Query With Matchers
+
+  Scenario: Matching Rows
+    Given these rows are available in table "my_table" of database "my_db"
+      | id   | foo           | amount | deleted_at |
+      | $id1 | ~/^foo-\d+$/  | > 100  | <any>      |
+
+    Then these rows are available in table "my_table" of database "my_db"
+      | id       | foo          | amount   | deleted_at |
+      | != $id1  | != foo-1     | <= 100   | <not null> |
+
+    And these rows are not available in table "my_table" of database "my_db"
+      | id | foo       | amount |
+      | 1  | ~/^bar-/  | <any>  |
+
+    And only these rows are available in table "my_table" of database "my_db" ordered by "id"
+      | id   | foo     | amount  | deleted_at |
+      | $id1 | <any>   | >= 150  | <any>      |
+      | 2    | foo-2   | < 150   | <not null> |
+
+  Scenario: Mismatching Row
+    Then these rows are available in table "my_table" of database "my_db"
+      | id | foo          |
+      | 1  | ~/^bar-\d+$/ |
@@ -0,0 +1,12 @@
+Feature: Database Negative Query
+
+  Scenario: Rows Not Available
+    Then these rows are not available in table "my_table" of database "my_db"
+      | id | foo   | meta          |
+      | 4  | foo-1 | NULL          |
+      | 1  | foo-1 | {"key":"baz"} |
+
+  Scenario: Row Available
+    Then these rows are not available in table "my_table" of database "my_db"
+      | id | foo   | meta          |
+      | 1  | foo-1 | {"key":"bar"} |
@@ -0,0 +1,13 @@
+Feature: Database Ordered Query
+
+  Scenario: Rows In Order
+    Then only these rows are available in table "my_table" of database "my_db" ordered by "created_at desc"
+      | id   | foo   | created_at           |
+      | $id2 | foo-2 | 2021-01-02T00:00:00Z |
+      | $id1 | foo-1 | 2021-01-01T00:00:00Z |
+
+  Scenario: Rows Out Of Order
+    Then only these rows are available in table "my_table" of database "my_db" ordered by "created_at"
+      | id | foo   | created_at           |
+      | 2  | foo-2 | 2021-01-02T00:00:00Z |
+      | 1  | foo-1 | 2021-01-01T00:00:00Z |
@@ -0,0 +1,21 @@
+Feature: Database Query Result
+
+  Scenario: Assert query result
+    Given these rows are available in table "my_table" of database "my_db"
+      | id   | foo   |
+      | $id1 | foo-1 |
+
+    When I query database "my_db" with this SQL:
+    """
+    SELECT t.foo, COUNT(1) AS cnt, MAX(a.bar) AS bar FROM my_table t LEFT JOIN my_another_table a ON a.id = t.id WHERE t.id >= $id1 GROUP BY t.foo ORDER BY t.foo
+    """
+
+    Then the query result matches:
+      | foo   | cnt | bar  |
+      | foo-1 | 2   | $bar |
+      | foo-2 | $n  | NULL |
+
+    And the query result matches:
+      | foo   | cnt |
+      | foo-1 | 2   |
+      | foo-2 | 2   |
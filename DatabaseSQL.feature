@@ -0,0 +1,17 @@
+Feature: Database Raw SQL
+
+  Scenario: Execute SQL
+    Given these rows are available in table "my_table" of database "my_db"
+      | id   | foo    |
+      | $id1 | $foo1  |
+
+    When I execute this SQL in database "my_db":
+    """
+    ALTER SEQUENCE my_table_id_seq RESTART WITH 100;
+    UPDATE my_table SET foo = 'it''s; $id1' WHERE id = $id1 AND foo = $foo1 AND id <> $id1_other; -- $id1
+    """
+
+    Then I execute this SQL in database "my_db":
+    """
+    SELECT broken
+    """
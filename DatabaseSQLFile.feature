@@ -0,0 +1,8 @@
+Feature: Database SQL File
+
+  Scenario: Apply SQL file
+    Given these rows are available in table "my_table" of database "my_db"
+      | id   | foo   |
+      | $id1 | foo-1 |
+
+    When the SQL file "_testdata/base.sql" is applied to database "my_db"
@@ -0,0 +1,4 @@
+Feature: Database Save
+
+  Scenario: Rows of table are saved to file
+    Given rows of table "my_table" of database "my_db" are saved to file "saved.csv"
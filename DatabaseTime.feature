@@ -0,0 +1,14 @@
+Feature: Database Relative Time
+
+  Scenario: Relative Time
+    Given these rows are stored in table "my_table" of database "my_db"
+      | id | created_at | deleted_at |
+      | 1  | now-1h     | NULL       |
+
+    Then these rows are available in table "my_table" of database "my_db"
+      | id | created_at       | deleted_at |
+      | 1  | ~now-1h±1m       | NULL       |
+
+    And these rows are available in table "my_table" of database "my_db"
+      | id | created_at  | deleted_at |
+      | 1  | > today-1d  | <any>      |
@@ -0,0 +1,4 @@
+Feature: Database Step Timeout
+
+  Scenario: Hung query fails the step
+    Then there are 3 rows in table "my_table" of database "my_db"
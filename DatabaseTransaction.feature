@@ -0,0 +1,9 @@
+Feature: Database Transaction Per Scenario
+
+  Scenario: Changes are rolled back
+    Given these rows are stored in table "my_table" of database "my_db"
+      | id | foo   |
+      | 1  | foo-1 |
+
+    Then application joins transaction of database "my_db"
+    And there is 1 row in table "my_table" of database "my_db"
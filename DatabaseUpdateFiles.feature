@@ -0,0 +1,7 @@
+Feature: Database Update Files
+
+  Scenario: Stale file is updated with table rows
+    Then only rows from this file are available in table "my_table" of database "my_db"
+    """
+    golden.csv
+    """
@@ -0,0 +1,11 @@
+-- Baseline data; generated with pg_dump.
+INSERT INTO my_table (id, foo) VALUES (100, 'it''s; fine');
+
+/* Function body contains semicolons. */
+CREATE FUNCTION my_func() RETURNS int AS $body$
+BEGIN
+    RETURN 1;
+END;
+$body$ LANGUAGE plpgsql;
+
+UPDATE my_table SET foo = 'foo-2' WHERE id = $id1;
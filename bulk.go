@@ -0,0 +1,276 @@
+package dbdog
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bool64/sqluct"
+)
+
+const defaultBulkBatchSize = 1000
+
+// bulkAssertion matches decoded gherkin rows with table rows received in a few queries.
+type bulkAssertion struct {
+	*tableQuery
+
+	received []map[string]interface{}
+	indexes  map[string]map[string][]int
+
+	// allReceived is true when all rows of table are received.
+	allReceived bool
+}
+
+// assertRowsBulk receives rows that can match gherkin rows and matches them in memory.
+//
+// Rows of file are matched in batches, table rows are received for every batch.
+func (t *tableQuery) assertRowsBulk() error {
+	b := &bulkAssertion{
+		tableQuery: t,
+	}
+
+	var onSetErr error
+
+	replaces, err := t.makeReplaces(&onSetErr)
+	if err != nil {
+		return err
+	}
+
+	err = t.eachBatch(func(offset int, data [][]string) error {
+		if err := b.fetch(); err != nil {
+			return err
+		}
+
+		return t.mapper.IterateTable(IterateConfig{
+			Data:       data,
+			Item:       t.row,
+			SkipDecode: t.skipDecode,
+			Replaces:   replaces,
+			ReceiveRow: withOffset(offset, b.receiveRow),
+		})
+	})
+
+	if err == nil && onSetErr != nil {
+		err = onSetErr
+	}
+
+	return err
+}
+
+// fetch receives table rows.
+//
+// If there is a column with exact non-NULL value in every gherkin row, rows are filtered by values of
+// that column in batches with IN condition, otherwise all rows of table are received once.
+func (b *bulkAssertion) fetch() error {
+	if b.allReceived {
+		return nil
+	}
+
+	col, values, err := b.keyValues()
+	if err != nil {
+		return err
+	}
+
+	b.received = b.received[:0]
+	b.indexes = make(map[string]map[string][]int)
+
+	it, err := itemType(b.row)
+	if err != nil {
+		return err
+	}
+
+	qb := b.storage.QueryBuilder().
+		Select(b.colNames...).
+		From(b.table)
+
+	var batches []squirrel.SelectBuilder
+
+	if col == "" {
+		batches = append(batches, qb)
+		b.allReceived = true
+	}
+
+	for start := 0; start < len(values); start += b.bulkBatchSize {
+		end := start + b.bulkBatchSize
+		if end > len(values) {
+			end = len(values)
+		}
+
+		batches = append(batches, qb.Where(squirrel.Eq{col: values[start:end]}))
+	}
+
+	colOption := sqluct.Columns(b.colNames...)
+
+	for _, bq := range batches {
+		dest := reflect.New(reflect.SliceOf(it))
+
+		if err := b.storage.Select(b.ctx, bq, dest.Interface()); err != nil {
+			return statementError(bq, err)
+		}
+
+		rows := dest.Elem()
+
+		for i := 0; i < rows.Len(); i++ {
+			b.received = append(b.received, combine(b.storage.Mapper.ColumnsValues(rows.Index(i), colOption)))
+		}
+	}
+
+	return nil
+}
+
+// keyValues finds first column with exact non-NULL value in every gherkin row and returns its unique values.
+func (b *bulkAssertion) keyValues() (string, []interface{}, error) {
+	replaces, err := b.varReplaces()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var (
+		candidates map[string][]interface{}
+		seen       = make(map[string]bool)
+	)
+
+	err = b.mapper.IterateTable(IterateConfig{
+		Data:       b.data,
+		Item:       b.row,
+		SkipDecode: b.skipDecode,
+		Replaces:   replaces,
+		ReceiveRow: func(_ int, row interface{}, _ []string, _ []string) error {
+			_, exact := b.rowValues(row)
+
+			// Only columns are needed, so conditions and post checks are discarded.
+			b.conditions = nil
+			b.postCheck = b.postCheck[:0]
+
+			if candidates == nil {
+				candidates = make(map[string][]interface{}, len(exact))
+
+				for col := range exact {
+					candidates[col] = nil
+				}
+			}
+
+			for col, values := range candidates {
+				v, ok := exact[col]
+				if !ok || plainValue(v) == nil {
+					delete(candidates, col)
+
+					continue
+				}
+
+				if h := col + "\x00" + hashValue(v); !seen[h] {
+					seen[h] = true
+					candidates[col] = append(values, v)
+				}
+			}
+
+			return nil
+		},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, col := range b.colNames {
+		if values, ok := candidates[col]; ok {
+			return col, values, nil
+		}
+	}
+
+	return "", nil, nil
+}
+
+// rowValues returns column values of decoded gherkin row and values of columns that are compared exactly.
+func (b *bulkAssertion) rowValues(row interface{}) (args, exact map[string]interface{}) {
+	args = combine(b.storage.Mapper.ColumnsValues(reflect.ValueOf(row), sqluct.Columns(b.colNames...)))
+	exact = make(map[string]interface{}, len(args))
+
+	for col, v := range args {
+		exact[col] = v
+	}
+
+	for _, sk := range b.skipWhereCols {
+		delete(exact, sk)
+	}
+
+	b.skipWhereCols = b.skipWhereCols[:0]
+
+	return args, exact
+}
+
+func (b *bulkAssertion) receiveRow(index int, row interface{}, _ []string, rawValues []string) error {
+	conditions, err := b.resolveConditions()
+	if err != nil {
+		return fmt.Errorf("row %d: %w", index, err)
+	}
+
+	argsExp, exact := b.rowValues(row)
+
+	pc := b.postCheck
+	b.postCheck = b.postCheck[:0]
+
+	cols := make([]string, 0, len(exact))
+
+	for _, col := range b.colNames {
+		if _, ok := exact[col]; ok {
+			cols = append(cols, col)
+		}
+	}
+
+	err = sql.ErrNoRows
+
+	for _, i := range b.index(cols)[hashValues(cols, exact)] {
+		argsRcv := b.received[i]
+
+		if err = b.checkConditions(conditions, argsRcv); err == nil {
+			return b.doPostCheck(b.colNames, pc, argsExp, argsRcv, rawValues)
+		}
+	}
+
+	return fmt.Errorf("failed to find row %d (%+v) in %d received rows: %w", index, row, len(b.received), err)
+}
+
+// index returns positions of received rows by hash of values of columns.
+func (b *bulkAssertion) index(cols []string) map[string][]int {
+	key := strings.Join(cols, ",")
+
+	if idx, ok := b.indexes[key]; ok {
+		return idx
+	}
+
+	idx := make(map[string][]int, len(b.received))
+
+	for i, args := range b.received {
+		h := hashValues(cols, args)
+		idx[h] = append(idx[h], i)
+	}
+
+	b.indexes[key] = idx
+
+	return idx
+}
+
+func hashValues(cols []string, args map[string]interface{}) string {
+	var sb strings.Builder
+
+	for _, col := range cols {
+		sb.WriteString(hashValue(args[col]))
+		sb.WriteByte(0)
+	}
+
+	return sb.String()
+}
+
+// hashValue makes a string that is equal for equal Go values of row fields.
+func hashValue(v interface{}) string {
+	v = indirect(v)
+
+	if t, ok := v.(time.Time); ok {
+		return "time.Time:" + t.UTC().Format(time.RFC3339Nano)
+	}
+
+	return fmt.Sprintf("%T:%v", v, v)
+}
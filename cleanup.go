@@ -0,0 +1,205 @@
+package dbdog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bool64/sqluct"
+	"github.com/cucumber/godog"
+)
+
+const (
+	postgresForeignKeys = `SELECT DISTINCT tc.table_name AS child, ccu.table_name AS parent
+FROM information_schema.table_constraints tc
+JOIN information_schema.constraint_column_usage ccu
+ON ccu.constraint_schema = tc.constraint_schema AND ccu.constraint_name = tc.constraint_name
+WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema()`
+
+	mysqlForeignKeys = `SELECT DISTINCT table_name AS child, referenced_table_name AS parent
+FROM information_schema.referential_constraints
+WHERE constraint_schema = DATABASE()`
+)
+
+const (
+	// AllTables is a wildcard for Instance.Cleanup to clean all tables of instance.
+	AllTables = "*"
+
+	// KeepDBTag is a scenario tag to skip automatic cleanup configured with Instance.Cleanup.
+	KeepDBTag = "@keep-db"
+)
+
+// cleanupInstances deletes rows from tables configured with Instance.Cleanup.
+func (m *Manager) cleanupInstances(ctx context.Context, sc *godog.Scenario) error {
+	for _, tag := range sc.Tags {
+		if tag.Name == KeepDBTag {
+			return nil
+		}
+	}
+
+	dbNames := make([]string, 0, len(m.Instances))
+	for dbName := range m.Instances {
+		dbNames = append(dbNames, dbName)
+	}
+
+	sort.Strings(dbNames)
+
+	for _, dbName := range dbNames {
+		if err := m.cleanupInstance(ctx, dbName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) cleanupInstance(ctx context.Context, dbName string) error {
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
+	instance := m.Instances[dbName]
+
+	for _, tableName := range instance.Cleanup {
+		if tableName == AllTables {
+			return m.noRowsInAnyTableOfDatabase(ctx, dbName)
+		}
+
+		if _, ok := instance.Tables[tableName]; !ok {
+			return fmt.Errorf("%w %s in database %s", errUnknownTable, tableName, dbName)
+		}
+	}
+
+	for _, tableName := range instance.Cleanup {
+		if err := deleteRows(m.instanceContext(ctx, dbName), instance, tableName, dbName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) noRowsInAnyTableOfDatabase(ctx context.Context, dbName string) error {
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
+	instance, ok := m.Instances[dbName]
+	if !ok {
+		return fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+	}
+
+	dbCtx := m.instanceContext(ctx, dbName)
+
+	tables, err := cleanupOrder(dbCtx, instance.Storage, instance.Tables)
+	if err != nil {
+		return fmt.Errorf("failed to resolve order of tables in db %s: %w", dbName, err)
+	}
+
+	for _, tableName := range tables {
+		if err := deleteRows(dbCtx, instance, tableName, dbName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cleanupOrder returns table names ordered so that referencing tables precede referenced tables.
+//
+// Tables in a reference cycle are ordered arbitrarily.
+func cleanupOrder(ctx context.Context, storage *sqluct.Storage, tables map[string]interface{}) ([]string, error) {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	referencing, err := foreignKeys(ctx, storage, names)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ordered = make([]string, 0, len(names))
+		visited = make(map[string]bool, len(names))
+		visit   func(name string)
+	)
+
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+
+		visited[name] = true
+
+		for _, child := range referencing[name] {
+			visit(child)
+		}
+
+		ordered = append(ordered, name)
+	}
+
+	for _, name := range names {
+		visit(name)
+	}
+
+	return ordered, nil
+}
+
+type foreignKey struct {
+	Child  string `db:"child"`
+	Parent string `db:"parent"`
+}
+
+// foreignKeys returns names of referencing tables per referenced table.
+//
+// Only references between provided tables are returned, references are empty for unknown dialects.
+func foreignKeys(ctx context.Context, storage *sqluct.Storage, names []string) (map[string][]string, error) {
+	var fks []foreignKey
+
+	switch storage.DB().DriverName() {
+	case "postgres", "pgx":
+		if err := storage.Select(ctx, sqluct.StringStatement(postgresForeignKeys), &fks); err != nil {
+			return nil, err
+		}
+	case "mysql":
+		if err := storage.Select(ctx, sqluct.StringStatement(mysqlForeignKeys), &fks); err != nil {
+			return nil, err
+		}
+	case "sqlite", "sqlite3":
+		for _, name := range names {
+			var tableFKs []foreignKey
+
+			quoted := "'" + strings.ReplaceAll(name, "'", "''") + "'"
+			q := "SELECT " + quoted + ` AS child, "table" AS parent FROM pragma_foreign_key_list(` + quoted + ")"
+
+			if err := storage.Select(ctx, sqluct.StringStatement(q), &tableFKs); err != nil {
+				return nil, err
+			}
+
+			fks = append(fks, tableFKs...)
+		}
+	}
+
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+
+	referencing := make(map[string][]string)
+
+	for _, fk := range fks {
+		if fk.Child == fk.Parent || !known[fk.Child] || !known[fk.Parent] {
+			continue
+		}
+
+		referencing[fk.Parent] = append(referencing[fk.Parent], fk.Child)
+	}
+
+	for _, children := range referencing {
+		sort.Strings(children)
+	}
+
+	return referencing, nil
+}
@@ -0,0 +1,270 @@
+package dbdog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/bool64/sqluct"
+)
+
+var (
+	errNotPgxConn      = errors.New("not a github.com/jackc/pgx connection")
+	errConnUnavailable = errors.New("connection of transaction in context is not available")
+)
+
+// CopyFunc stores rows in table with COPY FROM STDIN, row values are ordered as columns.
+//
+// It is called with context that carries transaction of the scenario if it is available.
+type CopyFunc func(ctx context.Context, table string, columns []string, rows [][]interface{}) error
+
+// PQCopyFrom makes CopyFunc for Postgres database with github.com/lib/pq driver.
+//
+// Rows are copied with a prepared COPY statement in a transaction, transaction from context is used if available.
+func PQCopyFrom(storage *sqluct.Storage) CopyFunc {
+	return func(ctx context.Context, table string, columns []string, rows [][]interface{}) error {
+		quoted := make([]string, 0, len(columns))
+		for _, col := range columns {
+			quoted = append(quoted, quoteIdentifier(col))
+		}
+
+		query := "COPY " + quoteIdentifier(table) + " (" + strings.Join(quoted, ", ") + ") FROM STDIN"
+
+		return storage.InTx(ctx, func(ctx context.Context) (err error) {
+			stmt, err := sqluct.TxFromContext(ctx).PrepareContext(ctx, query)
+			if err != nil {
+				return fmt.Errorf("failed to prepare %q: %w", query, err)
+			}
+
+			defer func() {
+				if clErr := stmt.Close(); clErr != nil && err == nil {
+					err = clErr
+				}
+			}()
+
+			for i, row := range rows {
+				if _, err := stmt.ExecContext(ctx, row...); err != nil {
+					return fmt.Errorf("failed to copy row %d: %w", i, err)
+				}
+			}
+
+			// Empty exec flushes copied data.
+			_, err = stmt.ExecContext(ctx)
+
+			return err
+		})
+	}
+}
+
+// PgxCopyFrom makes CopyFunc for Postgres database with github.com/jackc/pgx stdlib driver (v4 or v5).
+//
+// Rows are copied with pgx.Conn.CopyFrom of raw connection. Transactions that are started by steps
+// (including Manager.TransactionPerScenario) are bound to a connection, so rows are copied in such transaction.
+// Other transactions in context are not supported, as their connection is not available.
+//
+// Connection is accessed with reflection, so that dbdog does not depend on pgx.
+func PgxCopyFrom(storage *sqluct.Storage) CopyFunc {
+	return func(ctx context.Context, table string, columns []string, rows [][]interface{}) error {
+		conn := connFromContext(ctx)
+
+		if conn == nil {
+			if sqluct.TxFromContext(ctx) != nil {
+				return errConnUnavailable
+			}
+
+			c, err := storage.DB().Conn(ctx)
+			if err != nil {
+				return err
+			}
+
+			defer c.Close() // nolint:errcheck // Copy error is more relevant.
+
+			conn = c
+		}
+
+		return conn.Raw(func(driverConn interface{}) error {
+			return pgxCopy(ctx, driverConn, table, columns, rows)
+		})
+	}
+}
+
+// pgxCopy calls CopyFrom of *pgx.Conn that is available with Conn method of pgx stdlib connection.
+func pgxCopy(ctx context.Context, driverConn interface{}, table string, columns []string, rows [][]interface{}) error {
+	connMethod := reflect.ValueOf(driverConn).MethodByName("Conn")
+	if !connMethod.IsValid() || connMethod.Type().NumIn() != 0 || connMethod.Type().NumOut() != 1 {
+		return fmt.Errorf("%w: %T", errNotPgxConn, driverConn)
+	}
+
+	copyFrom := connMethod.Call(nil)[0].MethodByName("CopyFrom")
+	if !copyFrom.IsValid() {
+		return fmt.Errorf("%w: %T", errNotPgxConn, driverConn)
+	}
+
+	src := &copySource{rows: rows, pos: -1}
+	ft := copyFrom.Type()
+
+	if ft.NumIn() != 4 || ft.NumOut() != 2 ||
+		!reflect.TypeOf(ctx).AssignableTo(ft.In(0)) ||
+		!reflect.TypeOf(columns).ConvertibleTo(ft.In(1)) ||
+		!reflect.TypeOf(columns).AssignableTo(ft.In(2)) ||
+		!reflect.TypeOf(src).AssignableTo(ft.In(3)) {
+		return fmt.Errorf("%w: unexpected CopyFrom signature %s", errNotPgxConn, ft)
+	}
+
+	// Table name is a pgx.Identifier, that is a slice of name parts.
+	tableName := reflect.ValueOf(strings.Split(table, ".")).Convert(ft.In(1))
+
+	res := copyFrom.Call([]reflect.Value{
+		reflect.ValueOf(ctx), tableName, reflect.ValueOf(columns), reflect.ValueOf(src),
+	})
+
+	if err, ok := res[1].Interface().(error); ok && err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// copySource implements pgx.CopyFromSource.
+type copySource struct {
+	rows [][]interface{}
+	pos  int
+}
+
+func (c *copySource) Next() bool {
+	c.pos++
+
+	return c.pos < len(c.rows)
+}
+
+func (c *copySource) Values() ([]interface{}, error) {
+	return c.rows[c.pos], nil
+}
+
+func (c *copySource) Err() error {
+	return nil
+}
+
+// defaultCopyFrom returns CopyFunc for Postgres drivers, or nil for other drivers.
+func defaultCopyFrom(storage *sqluct.Storage) CopyFunc {
+	switch storage.DB().DriverName() {
+	case "postgres":
+		return PQCopyFrom(storage)
+	case "pgx":
+		return PgxCopyFrom(storage)
+	default:
+		return nil
+	}
+}
+
+type connKey struct{}
+
+// connFromContext returns connection that runs transaction of context, if the transaction is started by steps.
+func connFromContext(ctx context.Context) *sql.Conn {
+	if sqluct.TxFromContext(ctx) == nil {
+		return nil
+	}
+
+	conn, _ := ctx.Value(connKey{}).(*sql.Conn)
+
+	return conn
+}
+
+// inConnTx runs fn in transaction bound to a dedicated connection, transaction from context is used if available.
+//
+// Connection is available to CopyFunc, so that COPY can be executed with raw connection in transaction.
+func inConnTx(ctx context.Context, storage *sqluct.Storage, fn func(ctx context.Context) error) (err error) {
+	if sqluct.TxFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	conn, err := storage.DB().Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	defer func() {
+		if clErr := conn.Close(); clErr != nil && err == nil {
+			err = clErr
+		}
+	}()
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(context.WithValue(sqluct.TxToContext(ctx, tx), connKey{}, conn.Conn)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w, failed to rollback: %v", err, rbErr)
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// quoteIdentifier quotes possibly schema-qualified name for Postgres.
+func quoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+
+	for i, p := range parts {
+		parts[i] = `"` + strings.ReplaceAll(p, `"`, `""`) + `"`
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// copyRows decodes rows with TableMapper and stores them with copyFrom.
+func (t *tableQuery) copyRows(copyFrom CopyFunc, offset int, data [][]string, replaces map[string]string) error {
+	var (
+		columns []string
+		rows    = make([][]interface{}, 0, len(data)-1)
+	)
+
+	colOption := sqluct.Columns(t.colNames...)
+
+	err := t.mapper.IterateTable(IterateConfig{
+		Data:     data,
+		Item:     t.row,
+		Replaces: replaces,
+		ReceiveRow: func(_ int, row interface{}, _ []string, _ []string) error {
+			var values []interface{}
+
+			columns, values = t.storage.Mapper.ColumnsValues(reflect.ValueOf(row), colOption)
+			rows = append(rows, values)
+
+			return nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := copyFrom(t.ctx, t.table, columns, rows); err != nil {
+		return fmt.Errorf("failed to copy rows %d-%d to table %s: %w", offset, offset+len(rows)-1, t.table, err)
+	}
+
+	return nil
+}
+
+// hasPendingVars checks if table has variables that are not yet populated.
+func (t *tableQuery) hasPendingVars(data [][]string) bool {
+	for _, row := range data[1:] {
+		for _, cell := range row {
+			if !isVar(t.vars, cell) {
+				continue
+			}
+
+			if _, found := t.vars.Get(cell); !found {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,86 @@
+package dbdog_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/bool64/dbdog"
+	"github.com/bool64/sqluct"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// pgxIdentifier, pgxCopySource and pgxConn mimic API of github.com/jackc/pgx.
+type pgxIdentifier []string
+
+type pgxCopySource interface {
+	Next() bool
+	Values() ([]interface{}, error)
+	Err() error
+}
+
+type pgxConn struct {
+	table   pgxIdentifier
+	columns []string
+	rows    [][]interface{}
+}
+
+func (c *pgxConn) CopyFrom(
+	_ context.Context, tableName pgxIdentifier, columnNames []string, rowSrc pgxCopySource,
+) (int64, error) {
+	c.table = tableName
+	c.columns = columnNames
+
+	for rowSrc.Next() {
+		values, err := rowSrc.Values()
+		if err != nil {
+			return 0, err
+		}
+
+		c.rows = append(c.rows, values)
+	}
+
+	return int64(len(c.rows)), rowSrc.Err()
+}
+
+var errNotImplemented = errors.New("not implemented")
+
+// pgxStdlibConn mimics github.com/jackc/pgx/stdlib.Conn.
+type pgxStdlibConn struct {
+	conn *pgxConn
+}
+
+func (c *pgxStdlibConn) Conn() *pgxConn { return c.conn }
+
+func (c *pgxStdlibConn) Prepare(string) (driver.Stmt, error) { return nil, errNotImplemented }
+
+func (c *pgxStdlibConn) Close() error { return nil }
+
+func (c *pgxStdlibConn) Begin() (driver.Tx, error) { return nil, errNotImplemented }
+
+type pgxConnector struct {
+	conn *pgxConn
+}
+
+func (c pgxConnector) Connect(context.Context) (driver.Conn, error) {
+	return &pgxStdlibConn{conn: c.conn}, nil
+}
+
+func (c pgxConnector) Driver() driver.Driver { return nil }
+
+func TestPgxCopyFrom(t *testing.T) {
+	pc := &pgxConn{}
+
+	storage := sqluct.NewStorage(sqlx.NewDb(sql.OpenDB(pgxConnector{conn: pc}), "pgx"))
+
+	err := dbdog.PgxCopyFrom(storage)(context.Background(), "public.my_table", []string{"id", "foo"},
+		[][]interface{}{{1, "foo-1"}, {2, "foo-2"}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, pgxIdentifier{"public", "my_table"}, pc.table)
+	assert.Equal(t, []string{"id", "foo"}, pc.columns)
+	assert.Equal(t, [][]interface{}{{1, "foo-1"}, {2, "foo-2"}}, pc.rows)
+}
@@ -0,0 +1,321 @@
+package dbdog
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/bool64/sqluct"
+)
+
+const (
+	// maxDiffRows limits number of rows to make a diff, table contents are exposed for larger tables.
+	maxDiffRows = 10000
+
+	// maxDiffLines limits number of rows of each kind in rendered diff.
+	maxDiffLines = 50
+)
+
+// rowsDiff is a difference between expected rows and table rows.
+type rowsDiff struct {
+	colNames   []string
+	missing    []missingRow
+	unexpected [][]string
+	received   [][]string
+}
+
+// missingRow is an expected row that is not found in table, with the closest table row.
+type missingRow struct {
+	cells      []string
+	closest    int
+	mismatched []bool
+}
+
+// exposeDiff adds difference between expected rows and table rows to error of exhaustive assertion.
+//
+// Table contents are exposed instead if diff is not available.
+func (t *tableQuery) exposeDiff(err error) error {
+	diff, diffErr := t.diff()
+	if diffErr != nil || diff == nil {
+		return t.exposeContents(err)
+	}
+
+	return fmt.Errorf("%w, rows diff:\n%v", err, diff.render())
+}
+
+// diff matches expected rows with table rows.
+func (t *tableQuery) diff() (*rowsDiff, error) {
+	if t.data == nil {
+		return nil, nil
+	}
+
+	cnt, err := t.rowsCount()
+	if err != nil || cnt > maxDiffRows {
+		return nil, err
+	}
+
+	received, err := t.receiveAll(maxDiffRows + 1)
+	if err != nil || len(received) > maxDiffRows {
+		return nil, err
+	}
+
+	d := rowsDiff{
+		colNames: t.colNames,
+		received: make([][]string, 0, len(received)),
+	}
+
+	for _, args := range received {
+		cells := make([]string, 0, len(t.colNames))
+
+		for _, col := range t.colNames {
+			s, err := t.mapper.Encode(indirect(args[col]))
+			if err != nil {
+				return nil, err
+			}
+
+			cells = append(cells, s)
+		}
+
+		d.received = append(d.received, cells)
+	}
+
+	replaces, err := t.varReplaces()
+	if err != nil {
+		return nil, err
+	}
+
+	// Leftovers of failed assertion are discarded.
+	t.conditions = nil
+	t.skipWhereCols = t.skipWhereCols[:0]
+	t.postCheck = t.postCheck[:0]
+
+	matched := make([]bool, len(received))
+
+	err = t.eachBatch(func(offset int, data [][]string) error {
+		return t.mapper.IterateTable(IterateConfig{
+			Data:       data,
+			Item:       t.row,
+			SkipDecode: t.skipDecode,
+			Replaces:   replaces,
+			ReceiveRow: func(index int, row interface{}, _ []string, rawValues []string) error {
+				mismatches, err := t.rowMismatches(row)
+				if err != nil {
+					return err
+				}
+
+				closest, closestCnt := -1, 0
+
+				for i, args := range received {
+					// Rows of ordered assertion are only matched with table row at the same position.
+					if t.orderBy != "" && i != offset+index {
+						continue
+					}
+
+					m := mismatches(args)
+					mc := 0
+
+					for _, mm := range m {
+						if mm {
+							mc++
+						}
+					}
+
+					if mc == 0 && !matched[i] {
+						matched[i] = true
+
+						return nil
+					}
+
+					if closest == -1 || mc < closestCnt {
+						closest, closestCnt = i, mc
+					}
+				}
+
+				mr := missingRow{cells: rawValues, closest: closest}
+				if closest != -1 {
+					mr.mismatched = mismatches(received[closest])
+				}
+
+				d.missing = append(d.missing, mr)
+
+				return nil
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, cells := range d.received {
+		if !matched[i] {
+			d.unexpected = append(d.unexpected, cells)
+		}
+	}
+
+	if len(d.missing) == 0 && len(d.unexpected) == 0 {
+		return nil, nil
+	}
+
+	return &d, nil
+}
+
+// receiveAll receives up to limit table rows with columns of expected rows.
+func (t *tableQuery) receiveAll(limit uint64) ([]map[string]interface{}, error) {
+	it, err := itemType(t.row)
+	if err != nil {
+		return nil, err
+	}
+
+	qb := t.storage.QueryBuilder().
+		Select(t.colNames...).
+		From(t.table).
+		Limit(limit)
+
+	if t.orderBy != "" {
+		qb = qb.OrderBy(t.orderBy)
+	}
+
+	dest := reflect.New(reflect.SliceOf(it))
+
+	if err := t.storage.Select(t.ctx, qb, dest.Interface()); err != nil {
+		return nil, statementError(qb, err)
+	}
+
+	colOption := sqluct.Columns(t.colNames...)
+	rows := dest.Elem()
+	res := make([]map[string]interface{}, 0, rows.Len())
+
+	for i := 0; i < rows.Len(); i++ {
+		res = append(res, combine(t.storage.Mapper.ColumnsValues(rows.Index(i), colOption)))
+	}
+
+	return res, nil
+}
+
+// rowMismatches makes a function that reports mismatched columns of table row for decoded expected row.
+func (t *tableQuery) rowMismatches(row interface{}) (func(argsRcv map[string]interface{}) []bool, error) {
+	conditions, err := t.resolveConditions()
+	if err != nil {
+		return nil, err
+	}
+
+	argsExp := combine(t.storage.Mapper.ColumnsValues(reflect.ValueOf(row), sqluct.Columns(t.colNames...)))
+
+	// Columns with not yet populated variables match any value, JSON values are compared as Go values.
+	anyValue := make(map[string]bool, len(t.skipWhereCols))
+
+	for _, col := range t.skipWhereCols {
+		if _, ok := conditions[col]; !ok {
+			anyValue[col] = true
+		}
+	}
+
+	for _, col := range t.postCheck {
+		delete(anyValue, col)
+	}
+
+	t.skipWhereCols = t.skipWhereCols[:0]
+	t.postCheck = t.postCheck[:0]
+
+	return func(argsRcv map[string]interface{}) []bool {
+		res := make([]bool, len(t.colNames))
+
+		for i, col := range t.colNames {
+			if c, ok := conditions[col]; ok {
+				res[i] = c.Check != nil && c.Check(c.operand, argsRcv[col]) != nil
+
+				continue
+			}
+
+			if anyValue[col] {
+				continue
+			}
+
+			res[i] = !valuesEqual(indirect(argsExp[col]), indirect(argsRcv[col]))
+		}
+
+		return res
+	}, nil
+}
+
+// render formats diff as a table, expected rows that are not found in table are marked with "-" and
+// followed by the closest table row marked with "~", mismatched cells of the closest row are wrapped in "*",
+// unexpected table rows are marked with "+".
+func (d *rowsDiff) render() string {
+	type line struct {
+		mark  string
+		cells []string
+	}
+
+	lines := []line{{mark: " ", cells: d.colNames}}
+
+	for i, mr := range d.missing {
+		if i == maxDiffLines {
+			lines = append(lines, line{mark: "-", cells: []string{fmt.Sprintf("... %d more", len(d.missing)-i)}})
+
+			break
+		}
+
+		lines = append(lines, line{mark: "-", cells: mr.cells})
+
+		if mr.closest == -1 {
+			continue
+		}
+
+		closest := make([]string, len(d.colNames))
+
+		for j, cell := range d.received[mr.closest] {
+			if mr.mismatched[j] {
+				cell = "*" + cell + "*"
+			}
+
+			closest[j] = cell
+		}
+
+		lines = append(lines, line{mark: "~", cells: closest})
+	}
+
+	for i, cells := range d.unexpected {
+		if i == maxDiffLines {
+			lines = append(lines, line{mark: "+", cells: []string{fmt.Sprintf("... %d more", len(d.unexpected)-i)}})
+
+			break
+		}
+
+		lines = append(lines, line{mark: "+", cells: cells})
+	}
+
+	width := make([]int, len(d.colNames))
+
+	for _, l := range lines {
+		if len(l.cells) != len(d.colNames) {
+			continue
+		}
+
+		for j, cell := range l.cells {
+			if len(cell) > width[j] {
+				width[j] = len(cell)
+			}
+		}
+	}
+
+	var sb strings.Builder
+
+	for _, l := range lines {
+		sb.WriteString(l.mark + " |")
+
+		if len(l.cells) != len(d.colNames) {
+			sb.WriteString(" " + strings.Join(l.cells, " ") + "\n")
+
+			continue
+		}
+
+		for j, cell := range l.cells {
+			sb.WriteString(" " + cell + strings.Repeat(" ", width[j]-len(cell)) + " |")
+		}
+
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
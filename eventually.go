@@ -0,0 +1,158 @@
+package dbdog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bool64/shared"
+	"github.com/cucumber/godog"
+)
+
+const (
+	defaultEventuallyTimeout  = 10 * time.Second
+	defaultEventuallyInterval = 100 * time.Millisecond
+)
+
+func (m *Manager) registerEventualAssertions(s *godog.ScenarioContext) {
+	s.Step(`eventually only rows from this file are available in table "([^"]*)" of database "([^"]*)"(?: within ([^\s:]+))?[:]?$`,
+		func(ctx context.Context, tableName, database, within string, filePath *godog.DocString) error {
+			return m.eventuallyRowsFromThisFileAreAvailableInTableOfDatabase(ctx, tableName, database, filePath.Content, within, true)
+		})
+
+	s.Step(`eventually only these rows are available in table "([^"]*)" of database "([^"]*)"(?: within ([^\s:]+))?[:]?$`,
+		func(ctx context.Context, tableName, database, within string, data *godog.Table) error {
+			return m.eventuallyAssertRows(ctx, tableName, database, Rows(data), nil, true, within)
+		})
+
+	s.Step(`eventually only rows from this file are available in table "([^"]*)"(?: within ([^\s:]+))?[:]?$`,
+		func(ctx context.Context, tableName, within string, filePath *godog.DocString) error {
+			return m.eventuallyRowsFromThisFileAreAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase, filePath.Content, within, true)
+		})
+
+	s.Step(`eventually only these rows are available in table "([^"]*)"(?: within ([^\s:]+))?[:]?$`,
+		func(ctx context.Context, tableName, within string, data *godog.Table) error {
+			return m.eventuallyAssertRows(ctx, tableName, DefaultDatabase, Rows(data), nil, true, within)
+		})
+
+	s.Step(`eventually rows from this file are available in table "([^"]*)" of database "([^"]*)"(?: within ([^\s:]+))?[:]?$`,
+		func(ctx context.Context, tableName, database, within string, filePath *godog.DocString) error {
+			return m.eventuallyRowsFromThisFileAreAvailableInTableOfDatabase(ctx, tableName, database, filePath.Content, within, false)
+		})
+
+	s.Step(`eventually these rows are available in table "([^"]*)" of database "([^"]*)"(?: within ([^\s:]+))?[:]?$`,
+		func(ctx context.Context, tableName, database, within string, data *godog.Table) error {
+			return m.eventuallyAssertRows(ctx, tableName, database, Rows(data), nil, false, within)
+		})
+
+	s.Step(`eventually rows from this file are available in table "([^"]*)"(?: within ([^\s:]+))?[:]?$`,
+		func(ctx context.Context, tableName, within string, filePath *godog.DocString) error {
+			return m.eventuallyRowsFromThisFileAreAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase, filePath.Content, within, false)
+		})
+
+	s.Step(`eventually these rows are available in table "([^"]*)"(?: within ([^\s:]+))?[:]?$`,
+		func(ctx context.Context, tableName, within string, data *godog.Table) error {
+			return m.eventuallyAssertRows(ctx, tableName, DefaultDatabase, Rows(data), nil, false, within)
+		})
+}
+
+func (m *Manager) eventuallyRowsFromThisFileAreAvailableInTableOfDatabase(
+	ctx context.Context, tableName, dbName, filePath, within string, exhaustiveList bool,
+) error {
+	return m.assertFile(ctx, tableName, dbName, filePath, "", exhaustiveList, func(tf *tableFile) error {
+		return m.eventuallyAssertRows(ctx, tableName, dbName, nil, tf, exhaustiveList, within)
+	})
+}
+
+func (m *Manager) eventuallyTiming(within string) (timeout, interval time.Duration, err error) {
+	timeout = m.EventuallyTimeout
+	if timeout == 0 {
+		timeout = defaultEventuallyTimeout
+	}
+
+	if within != "" {
+		timeout, err = time.ParseDuration(within)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse time limit %q: %w", within, err)
+		}
+	}
+
+	interval = m.EventuallyInterval
+	if interval == 0 {
+		interval = defaultEventuallyInterval
+	}
+
+	return timeout, interval, nil
+}
+
+// eventuallyAssertRows retries rows assertion until it passes or time limit is reached.
+//
+// Every attempt collects variables in a scratch copy of scenario variables,
+// so that values received during failed attempts do not affect next attempts.
+// Retries stop when context is done or an attempt exceeds Manager.StepTimeout.
+func (m *Manager) eventuallyAssertRows(
+	ctx context.Context, tableName, dbName string, data [][]string, tf *tableFile, exhaustiveList bool, within string,
+) error {
+	timeout, interval, err := m.eventuallyTiming(within)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		t, err := m.makeTableQueryOf(ctx, tableName, dbName, data, tf)
+		if err != nil {
+			return err
+		}
+
+		vars := t.vars
+		t.vars = &shared.Vars{VarPrefix: vars.VarPrefix}
+
+		for k, v := range vars.GetAll() {
+			t.vars.Set(k, v)
+		}
+
+		// Step timeout limits every attempt, table contents are exposed with context of the step.
+		dbCtx := t.ctx
+		attemptCtx, cancel := m.stepContext(dbCtx)
+		t.ctx = attemptCtx
+
+		err = t.assertRows(exhaustiveList)
+		attemptErr := attemptCtx.Err()
+
+		cancel()
+
+		t.ctx = dbCtx
+
+		if err == nil {
+			for k, v := range t.vars.GetAll() {
+				if _, found := vars.Get(k); !found {
+					vars.Set(k, v)
+				}
+			}
+
+			return nil
+		}
+
+		// Attempt that exceeded step timeout or was cancelled is not retried.
+		if attemptErr != nil {
+			return fmt.Errorf("assertion did not pass: %w: %v", attemptErr, err)
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			// Expose table contents to simplify test debugging.
+			return t.expose(fmt.Errorf("assertion did not pass within %s: %w", timeout, err), exhaustiveList)
+		}
+
+		timer := time.NewTimer(interval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return fmt.Errorf("assertion did not pass: %w: %v", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package dbdog
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+const defaultFileBatchSize = 1000
+
+var (
+	errMissingFileName = errors.New("missing file name")
+	errInvalidPath     = errors.New("invalid path")
+	errReadOnlyFS      = errors.New("files can not be written with Manager.FixturesFS")
+)
+
+// resolvePath replaces known variables in path of file and makes it relative to Manager.FixturesDir.
+//
+// Paths of Manager.FixturesFS are slash-separated, absolute paths are only allowed for OS file system.
+func (m *Manager) resolvePath(ctx context.Context, filePath string) (string, error) {
+	if filePath == "" {
+		return "", errMissingFileName
+	}
+
+	filePath, err := m.replaceVars(ctx, filePath, m.pathValue)
+	if err != nil {
+		return "", err
+	}
+
+	if m.FixturesFS != nil {
+		resolved := path.Join(m.FixturesDir, filePath)
+		if !fs.ValidPath(resolved) {
+			return "", fmt.Errorf("%w %s", errInvalidPath, resolved)
+		}
+
+		return resolved, nil
+	}
+
+	if m.FixturesDir != "" && !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(m.FixturesDir, filePath)
+	}
+
+	return filePath, nil
+}
+
+// writablePath resolves path of file to write, files can only be written to OS file system.
+func (m *Manager) writablePath(ctx context.Context, filePath string) (string, error) {
+	if m.FixturesFS != nil {
+		return "", errReadOnlyFS
+	}
+
+	return m.resolvePath(ctx, filePath)
+}
+
+// pathValue formats variable value for a file path.
+func (m *Manager) pathValue(v interface{}) (string, error) {
+	v = plainValue(v)
+	if v == nil {
+		return null, nil
+	}
+
+	return m.TableMapper.Encode(v)
+}
+
+// openResolved opens file of Manager.FixturesFS, or of OS file system if it is not set.
+func (m *Manager) openResolved(filePath string) (fs.File, error) {
+	if m.FixturesFS != nil {
+		return m.FixturesFS.Open(filePath)
+	}
+
+	return os.Open(filePath) // nolint:gosec // Intended file inclusion.
+}
+
+// readResolved reads file of Manager.FixturesFS, or of OS file system if it is not set.
+func (m *Manager) readResolved(filePath string) ([]byte, error) {
+	if m.FixturesFS != nil {
+		return fs.ReadFile(m.FixturesFS, filePath)
+	}
+
+	return ioutil.ReadFile(filePath) // nolint:gosec // Intended file inclusion.
+}
+
+// tableFile reads rows of file in batches, so that large files are not loaded in memory at once.
+//
+// File format is detected by extension, see fileFormat.
+type tableFile struct {
+	f         fs.File
+	open      func() (fs.File, error)
+	path      string
+	r         recordReader
+	format    string
+	header    []string
+	batchSize int
+}
+
+func openTableFile(filePath string, open func(filePath string) (fs.File, error), batchSize int) (*tableFile, error) {
+	f, err := open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if batchSize <= 0 {
+		batchSize = defaultFileBatchSize
+	}
+
+	tf := &tableFile{
+		f: f,
+		open: func() (fs.File, error) {
+			return open(filePath)
+		},
+		path:      filePath,
+		format:    fileFormat(filePath),
+		batchSize: batchSize,
+	}
+
+	if err := tf.reset(); err != nil {
+		_ = tf.f.Close() // nolint:errcheck // Read error is more relevant.
+
+		return nil, err
+	}
+
+	return tf, nil
+}
+
+// reset rewinds file to the first row after header.
+//
+// Files that can not seek, for example of some fs.FS implementations, are reopened.
+func (tf *tableFile) reset() error {
+	if s, ok := tf.f.(io.Seeker); ok {
+		if _, err := s.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	} else {
+		if err := tf.f.Close(); err != nil {
+			return err
+		}
+
+		f, err := tf.open()
+		if err != nil {
+			return err
+		}
+
+		tf.f = f
+	}
+
+	tf.r = newRecordReader(tf.f, tf.format)
+
+	header, err := tf.r.Read()
+	if errors.Is(err, io.EOF) {
+		return errRowRequired
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", tf.format, err)
+	}
+
+	tf.header = header
+
+	return nil
+}
+
+// next returns header and up to batchSize next rows, there are no rows in returned table at the end of file.
+func (tf *tableFile) next() ([][]string, error) {
+	data := make([][]string, 1, tf.batchSize+1)
+	data[0] = tf.header
+
+	for len(data) <= tf.batchSize {
+		rec, err := tf.r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", tf.format, err)
+		}
+
+		data = append(data, rec)
+	}
+
+	return data, nil
+}
+
+// count returns number of rows in file and rewinds it.
+func (tf *tableFile) count() (int, error) {
+	if err := tf.reset(); err != nil {
+		return 0, err
+	}
+
+	if r, ok := tf.r.(*csv.Reader); ok {
+		r.ReuseRecord = true
+	}
+
+	cnt := 0
+
+	for {
+		_, err := tf.r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %s: %w", tf.format, err)
+		}
+
+		cnt++
+	}
+
+	return cnt, tf.reset()
+}
+
+// Close closes file.
+func (tf *tableFile) Close() error {
+	return tf.f.Close()
+}
+
+// openFile opens file with rows for a table, path of file is resolved with resolvePath.
+func (m *Manager) openFile(ctx context.Context, filePath string) (*tableFile, error) {
+	filePath, err := m.resolvePath(ctx, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rows from file: %w", err)
+	}
+
+	tf, err := openTableFile(filePath, m.openResolved, m.FileBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rows from file %s: %w", filePath, err)
+	}
+
+	return tf, nil
+}
+
+// closeFile closes file and reports error if there was no other error.
+func closeFile(tf *tableFile, err *error) {
+	if clErr := tf.Close(); clErr != nil && *err == nil {
+		*err = clErr
+	}
+}
+
+// makeTableQueryOf makes table query with rows of gherkin table, or of file if it is not nil.
+//
+// Rows of file are received in batches with eachBatch.
+func (m *Manager) makeTableQueryOf(ctx context.Context, tableName, dbName string, data [][]string, tf *tableFile) (*tableQuery, error) {
+	if tf == nil {
+		return m.makeTableQuery(ctx, tableName, dbName, data)
+	}
+
+	t, err := m.makeTableQuery(ctx, tableName, dbName, [][]string{tf.header})
+	if err != nil {
+		return nil, err
+	}
+
+	t.file = tf
+
+	return t, nil
+}
+
+// rowsCount returns number of rows in gherkin table or file.
+func (t *tableQuery) rowsCount() (int, error) {
+	if t.file != nil {
+		return t.file.count()
+	}
+
+	if t.data == nil {
+		return 0, nil
+	}
+
+	return len(t.data) - 1, nil
+}
+
+// eachBatch calls fn with gherkin table or with batches of rows from file,
+// offset is an index of the first row of batch.
+//
+// Current batch is available as tableQuery.data.
+func (t *tableQuery) eachBatch(fn func(offset int, data [][]string) error) error {
+	if t.file == nil {
+		return fn(0, t.data)
+	}
+
+	if err := t.file.reset(); err != nil {
+		return err
+	}
+
+	offset := 0
+
+	for {
+		data, err := t.file.next()
+		if err != nil {
+			return err
+		}
+
+		// Empty batch is only processed if there are no rows at all to report missing rows.
+		if len(data) == 1 && offset > 0 {
+			return nil
+		}
+
+		t.data = data
+
+		if err := fn(offset, data); err != nil {
+			return err
+		}
+
+		if len(data)-1 < t.file.batchSize {
+			return nil
+		}
+
+		offset += len(data) - 1
+	}
+}
+
+type rowReceiver func(index int, row interface{}, colNames []string, rawValues []string) error
+
+// withOffset makes row receiver that adds offset to row index.
+func withOffset(offset int, receive rowReceiver) rowReceiver {
+	if offset == 0 {
+		return receive
+	}
+
+	return func(index int, row interface{}, colNames []string, rawValues []string) error {
+		return receive(offset+index, row, colNames, rawValues)
+	}
+}
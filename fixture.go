@@ -0,0 +1,193 @@
+package dbdog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/bool64/sqluct"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	errTablesExpected = errors.New("object with rows keyed by table name expected")
+	errDuplicateTable = errors.New("duplicate table")
+)
+
+// fixtureTable is a table of fixture with rows as a gherkin table.
+type fixtureTable struct {
+	name string
+	data [][]string
+}
+
+// loadFixture reads YAML or JSON document with arrays of rows keyed by table name.
+//
+// Tables are returned in order of document, tables with empty arrays are skipped.
+func (m *Manager) loadFixture(ctx context.Context, filePath string) ([]fixtureTable, error) {
+	filePath, err := m.resolvePath(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if format := fileFormat(filePath); format != formatYAML && format != formatJSON {
+		return nil, fmt.Errorf("%w %s, fixture must be YAML or JSON", errUnsupportedFormat, format)
+	}
+
+	f, err := m.openResolved(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close() // nolint:errcheck // File is only read.
+
+	// JSON is a subset of YAML, so the same decoder serves both formats.
+	var doc yaml.Node
+
+	if err := yaml.NewDecoder(f).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%w in %s", errTablesExpected, filePath)
+	}
+
+	root := doc.Content[0]
+	tables := make([]fixtureTable, 0, len(root.Content)/2)
+	seen := make(map[string]bool, len(root.Content)/2)
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		name, rows := root.Content[i].Value, root.Content[i+1]
+
+		if seen[name] {
+			return nil, fmt.Errorf("%w %s at %s:%d", errDuplicateTable, name, filePath, root.Content[i].Line)
+		}
+
+		seen[name] = true
+
+		if rows.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("table %s at %s:%d: %w", name, filePath, rows.Line, errArrayOfObjectsExpected)
+		}
+
+		r := &objectsReader{next: yamlItems(rows.Content)}
+
+		var data [][]string
+
+		for {
+			rec, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("failed to read rows of table %s in %s: %w", name, filePath, err)
+			}
+
+			data = append(data, rec)
+		}
+
+		if data != nil {
+			tables = append(tables, fixtureTable{name: name, data: data})
+		}
+	}
+
+	return tables, nil
+}
+
+func (m *Manager) fixtureIsLoadedIntoDatabase(ctx context.Context, filePath, dbName string) error {
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
+	instance, ok := m.Instances[dbName]
+	if !ok {
+		return fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+	}
+
+	tables, err := m.loadFixture(ctx, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to load fixture: %w", err)
+	}
+
+	queries := make(map[string]*tableQuery, len(tables))
+	names := make([]string, 0, len(tables))
+
+	for _, ft := range tables {
+		t, err := m.makeTableQuery(ctx, ft.name, dbName, ft.data)
+		if err != nil {
+			return err
+		}
+
+		queries[ft.name] = t
+		names = append(names, ft.name)
+	}
+
+	dbCtx := m.instanceContext(ctx, dbName)
+
+	order, err := insertOrder(dbCtx, instance.Storage, names)
+	if err != nil {
+		return fmt.Errorf("failed to resolve order of tables in db %s: %w", dbName, err)
+	}
+
+	return inConnTx(dbCtx, instance.Storage, func(txCtx context.Context) error {
+		for _, name := range order {
+			t := queries[name]
+			t.ctx = txCtx
+
+			if err := m.storeRows(t, nil); err != nil {
+				return fmt.Errorf("failed to store rows of table %s: %w", name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// insertOrder returns table names ordered so that referenced tables precede referencing tables.
+//
+// Order of names is kept for tables that do not depend on each other, tables in a reference cycle
+// are ordered arbitrarily.
+func insertOrder(ctx context.Context, storage *sqluct.Storage, names []string) ([]string, error) {
+	referencing, err := foreignKeys(ctx, storage, names)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string][]string, len(referencing))
+
+	for parent, children := range referencing {
+		for _, child := range children {
+			referenced[child] = append(referenced[child], parent)
+		}
+	}
+
+	for _, parents := range referenced {
+		sort.Strings(parents)
+	}
+
+	var (
+		ordered = make([]string, 0, len(names))
+		visited = make(map[string]bool, len(names))
+		visit   func(name string)
+	)
+
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+
+		visited[name] = true
+
+		for _, parent := range referenced[name] {
+			visit(parent)
+		}
+
+		ordered = append(ordered, name)
+	}
+
+	for _, name := range names {
+		visit(name)
+	}
+
+	return ordered, nil
+}
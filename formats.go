@@ -0,0 +1,306 @@
+package dbdog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of files with rows.
+const (
+	formatCSV   = "CSV"
+	formatJSON  = "JSON"
+	formatJSONL = "JSONL"
+	formatYAML  = "YAML"
+)
+
+var (
+	errArrayOfObjectsExpected = errors.New("array of objects expected")
+	errEmptyObject            = errors.New("object without keys")
+	errMissingColumn          = errors.New("missing column")
+	errUnsupportedFormat      = errors.New("unsupported file format")
+)
+
+// fileFormat detects format of file with rows by extension, CSV is the default.
+func fileFormat(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return formatJSON
+	case ".jsonl", ".ndjson":
+		return formatJSONL
+	case ".yaml", ".yml":
+		return formatYAML
+	default:
+		return formatCSV
+	}
+}
+
+// recordReader reads rows of file as records of cells, the first record is a header.
+type recordReader interface {
+	Read() ([]string, error)
+}
+
+func newRecordReader(r io.Reader, format string) recordReader {
+	switch format {
+	case formatJSON:
+		return &objectsReader{next: jsonObjects(json.NewDecoder(r), true)}
+	case formatJSONL:
+		return &objectsReader{next: jsonObjects(json.NewDecoder(r), false)}
+	case formatYAML:
+		return &objectsReader{next: yamlObjects(r)}
+	default:
+		return csv.NewReader(r)
+	}
+}
+
+// objectsReader makes records of objects keyed by column names.
+//
+// Header is made of keys of the first object, other objects must have the same keys.
+type objectsReader struct {
+	next    func() (keys, values []string, err error)
+	started bool
+	header  []string
+	columns map[string]int
+	pending []string
+	row     int
+}
+
+func (o *objectsReader) Read() ([]string, error) {
+	if !o.started {
+		keys, values, err := o.next()
+		if err != nil {
+			return nil, err
+		}
+
+		o.started = true
+		o.row = 1
+
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("row %d: %w", o.row, errEmptyObject)
+		}
+
+		o.header = keys
+		o.pending = values
+		o.columns = make(map[string]int, len(keys))
+
+		for i, k := range keys {
+			o.columns[k] = i
+		}
+
+		return o.header, nil
+	}
+
+	if o.pending != nil {
+		rec := o.pending
+		o.pending = nil
+
+		return rec, nil
+	}
+
+	keys, values, err := o.next()
+	if err != nil {
+		return nil, err
+	}
+
+	o.row++
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("row %d: %w", o.row, errEmptyObject)
+	}
+
+	rec := make([]string, len(o.header))
+	set := make([]bool, len(o.header))
+
+	for i, k := range keys {
+		pos, ok := o.columns[k]
+		if !ok {
+			return nil, fmt.Errorf("row %d: %w %s", o.row, errUnknownColumn, k)
+		}
+
+		rec[pos] = values[i]
+		set[pos] = true
+	}
+
+	for pos, ok := range set {
+		if !ok {
+			return nil, fmt.Errorf("row %d: %w %s", o.row, errMissingColumn, o.header[pos])
+		}
+	}
+
+	return rec, nil
+}
+
+// jsonObjects reads objects from JSON array or from a stream of JSON objects (JSON Lines).
+func jsonObjects(dec *json.Decoder, array bool) func() (keys, values []string, err error) {
+	started := false
+
+	return func() (keys, values []string, err error) {
+		if !started && array {
+			if err := expectDelim(dec, '['); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		started = true
+
+		if !dec.More() {
+			return nil, nil, io.EOF
+		}
+
+		if err := expectDelim(dec, '{'); err != nil {
+			return nil, nil, err
+		}
+
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			key, ok := tok.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("%w, unexpected %v", errArrayOfObjectsExpected, tok)
+			}
+
+			var raw json.RawMessage
+
+			if err := dec.Decode(&raw); err != nil {
+				return nil, nil, err
+			}
+
+			value, err := jsonCell(raw)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+
+		// Closing brace of object.
+		if _, err := dec.Token(); err != nil {
+			return nil, nil, err
+		}
+
+		return keys, values, nil
+	}
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("%w, unexpected %v", errArrayOfObjectsExpected, tok)
+	}
+
+	return nil
+}
+
+// jsonCell converts JSON value to a cell, strings are unquoted, objects and arrays are kept as JSON.
+func jsonCell(raw json.RawMessage) (string, error) {
+	raw = bytes.TrimSpace(raw)
+
+	switch {
+	case string(raw) == "null":
+		return null, nil
+	case len(raw) > 0 && raw[0] == '"':
+		var s string
+
+		err := json.Unmarshal(raw, &s)
+
+		return s, err
+	case len(raw) > 0 && (raw[0] == '{' || raw[0] == '['):
+		buf := bytes.NewBuffer(nil)
+
+		err := json.Compact(buf, raw)
+
+		return buf.String(), err
+	default:
+		return string(raw), nil
+	}
+}
+
+// yamlObjects reads objects from YAML sequence of mappings, document is loaded at once.
+func yamlObjects(r io.Reader) func() (keys, values []string, err error) {
+	var next func() (keys, values []string, err error)
+
+	return func() (keys, values []string, err error) {
+		if next == nil {
+			var doc yaml.Node
+
+			if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+				return nil, nil, err
+			}
+
+			if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.SequenceNode {
+				return nil, nil, errArrayOfObjectsExpected
+			}
+
+			next = yamlItems(doc.Content[0].Content)
+		}
+
+		return next()
+	}
+}
+
+// yamlItems reads objects from items of YAML sequence.
+func yamlItems(items []*yaml.Node) func() (keys, values []string, err error) {
+	return func() (keys, values []string, err error) {
+		if len(items) == 0 {
+			return nil, nil, io.EOF
+		}
+
+		item := items[0]
+		items = items[1:]
+
+		if item.Kind != yaml.MappingNode {
+			return nil, nil, fmt.Errorf("%w at line %d", errArrayOfObjectsExpected, item.Line)
+		}
+
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			value, err := yamlCell(item.Content[i+1])
+			if err != nil {
+				return nil, nil, err
+			}
+
+			keys = append(keys, item.Content[i].Value)
+			values = append(values, value)
+		}
+
+		return keys, values, nil
+	}
+}
+
+// yamlCell converts YAML value to a cell, scalars are kept as is, mappings and sequences are converted to JSON.
+func yamlCell(n *yaml.Node) (string, error) {
+	if n.Kind == yaml.ScalarNode {
+		if n.ShortTag() == "!!null" {
+			return null, nil
+		}
+
+		return n.Value, nil
+	}
+
+	var v interface{}
+
+	if err := n.Decode(&v); err != nil {
+		return "", err
+	}
+
+	j, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert value at line %d to JSON: %w", n.Line, err)
+	}
+
+	return string(j), nil
+}
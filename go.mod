@@ -1,6 +1,6 @@
 module github.com/bool64/dbdog
 
-go 1.13
+go 1.16
 
 require (
 	github.com/DATA-DOG/go-sqlmock v1.5.0
@@ -15,4 +15,5 @@ require (
 	github.com/jmoiron/sqlx v1.3.4
 	github.com/stretchr/testify v1.7.0
 	github.com/swaggest/form/v5 v5.0.1
+	gopkg.in/yaml.v3 v3.0.1
 )
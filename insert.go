@@ -0,0 +1,363 @@
+package dbdog
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bool64/sqluct"
+)
+
+var (
+	errNoColumns       = errors.New("no columns to insert")
+	errAmbiguousInsert = errors.New("failed to receive generated values of inserted row")
+)
+
+// supportsReturning checks if database dialect supports INSERT ... RETURNING.
+//
+// Dialect is detected by driver name, unknown drivers are assumed to not support it.
+func supportsReturning(storage *sqluct.Storage) bool {
+	switch storage.DB().DriverName() {
+	case "postgres", "pgx", "sqlite", "sqlite3":
+		return true
+	default:
+		return false
+	}
+}
+
+// maxBindParams returns maximum number of bind parameters in a statement for database dialect.
+func maxBindParams(storage *sqluct.Storage) int {
+	switch storage.DB().DriverName() {
+	case "sqlite", "sqlite3":
+		return 32766
+	default:
+		// Postgres and MySQL limit.
+		return 65535
+	}
+}
+
+// rowInserter collects decoded rows and inserts them in chunks.
+//
+// Rows that have not yet populated variables are inserted individually without columns of such variables,
+// values of those columns are received from database and stored as variables.
+type rowInserter struct {
+	*tableQuery
+
+	batch reflect.Value
+
+	// batchStart is an index of first pending row.
+	batchStart int
+
+	// chunk is a number of inserted chunks.
+	chunk int
+
+	// copyFrom stores batches of rows without pending variables if it is not nil.
+	copyFrom CopyFunc
+}
+
+func (t *tableQuery) newRowInserter() (*rowInserter, error) {
+	if len(t.colNames) == 0 {
+		return nil, fmt.Errorf("%w in table %s", errNoColumns, t.table)
+	}
+
+	it, err := itemType(t.row)
+	if err != nil {
+		return nil, err
+	}
+
+	ri := &rowInserter{
+		tableQuery: t,
+	}
+
+	if ri.chunkSize <= 0 {
+		ri.chunkSize = maxBindParams(t.storage) / len(t.colNames)
+		if ri.chunkSize == 0 {
+			ri.chunkSize = 1
+		}
+	}
+
+	batchCap := len(t.data) - 1
+	if t.file != nil {
+		batchCap = t.file.batchSize
+	}
+
+	if batchCap > ri.chunkSize {
+		batchCap = ri.chunkSize
+	}
+
+	ri.batch = reflect.MakeSlice(reflect.SliceOf(it), 0, batchCap)
+
+	return ri, nil
+}
+
+// singleStatement checks if rows are inserted with a single statement, so that transaction is not needed.
+//
+// Multiple statements are needed for multiple chunks or batches of file, or to capture values of
+// not yet populated variables in multiple rows.
+func (r *rowInserter) singleStatement() (bool, error) {
+	cnt, err := r.rowsCount()
+	if err != nil {
+		return false, err
+	}
+
+	if cnt <= 1 {
+		return true, nil
+	}
+
+	if cnt > r.chunkSize {
+		return false, nil
+	}
+
+	data := r.data
+
+	if r.file != nil {
+		if cnt > r.file.batchSize {
+			return false, nil
+		}
+
+		if err := r.file.reset(); err != nil {
+			return false, err
+		}
+
+		if data, err = r.file.next(); err != nil {
+			return false, err
+		}
+	}
+
+	return !r.hasPendingVars(data), nil
+}
+
+// insert decodes and inserts rows.
+func (r *rowInserter) insert() error {
+	var onSetErr error
+
+	replaces, err := r.makeReplaces(&onSetErr)
+	if err != nil {
+		return err
+	}
+
+	// Reading and inserting rows.
+	err = r.eachBatch(func(offset int, data [][]string) error {
+		// Rows with not yet populated variables need to be inserted to receive generated values.
+		if r.copyFrom != nil && !r.hasPendingVars(data) {
+			// Pending rows are inserted before copied rows to keep the order of insertion.
+			if err := r.flush(); err != nil {
+				return err
+			}
+
+			return r.copyRows(r.copyFrom, offset, data, replaces)
+		}
+
+		return r.mapper.IterateTable(IterateConfig{
+			Data:       data,
+			Item:       r.row,
+			SkipDecode: r.skipDecode,
+			Replaces:   replaces,
+			ReceiveRow: withOffset(offset, r.receiveRow),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if onSetErr != nil {
+		return onSetErr
+	}
+
+	return r.flush()
+}
+
+// skipDecode excludes not yet populated variables from decoding.
+func (r *rowInserter) skipDecode(_, value string) bool {
+	if isVar(r.vars, value) {
+		_, found := r.vars.Get(value)
+
+		return !found
+	}
+
+	return false
+}
+
+func (r *rowInserter) receiveRow(index int, row interface{}, colNames []string, rawValues []string) error {
+	var capture map[string]string
+
+	for i, col := range colNames {
+		v := rawValues[i]
+
+		if r.skipDecode(col, v) {
+			if capture == nil {
+				capture = make(map[string]string)
+			}
+
+			capture[col] = v
+		}
+	}
+
+	if capture == nil {
+		if r.batch.Len() == 0 {
+			r.batchStart = index
+		}
+
+		r.batch = reflect.Append(r.batch, reflect.Indirect(reflect.ValueOf(row)))
+
+		if r.batch.Len() >= r.chunkSize {
+			return r.flush()
+		}
+
+		return nil
+	}
+
+	// Pending rows are inserted before the capturing row to keep the order of insertion.
+	if err := r.flush(); err != nil {
+		return err
+	}
+
+	if err := r.insertCapturing(row, capture); err != nil {
+		return fmt.Errorf("failed to insert row %d: %w", index, err)
+	}
+
+	return nil
+}
+
+// flush inserts pending rows.
+func (r *rowInserter) flush() error {
+	if r.batch.Len() == 0 {
+		return nil
+	}
+
+	stmt := r.storage.InsertStmt(r.table, r.batch.Interface(), sqluct.Columns(r.colNames...))
+	first, last := r.batchStart, r.batchStart+r.batch.Len()-1
+
+	r.batch = r.batch.Slice(0, 0)
+	r.chunk++
+
+	_, err := r.storage.Exec(r.ctx, stmt)
+	if err != nil {
+		query, args, toSQLErr := stmt.ToSql()
+		if toSQLErr != nil {
+			return toSQLErr
+		}
+
+		return fmt.Errorf("failed to insert chunk %d (rows %d-%d) %q, %v: %w", r.chunk, first, last, query, args, err)
+	}
+
+	return nil
+}
+
+// insertCapturing inserts a row without captured columns and populates variables with values of those columns.
+func (r *rowInserter) insertCapturing(row interface{}, capture map[string]string) error {
+	cols := make([]string, 0, len(r.colNames))
+	capCols := make([]string, 0, len(capture))
+
+	for _, col := range r.colNames {
+		if _, ok := capture[col]; ok {
+			capCols = append(capCols, col)
+		} else {
+			cols = append(cols, col)
+		}
+	}
+
+	dest := reflect.New(reflect.TypeOf(row).Elem()).Interface()
+	stmt := r.storage.InsertStmt(r.table, row, sqluct.Columns(cols...))
+
+	quoted := capCols
+	if r.storage.IdentifierQuoter != nil {
+		quoted = make([]string, 0, len(capCols))
+
+		for _, col := range capCols {
+			quoted = append(quoted, r.storage.IdentifierQuoter(col))
+		}
+	}
+
+	if supportsReturning(r.storage) {
+		stmt = stmt.Suffix("RETURNING " + strings.Join(quoted, ", "))
+
+		if err := r.storage.Select(r.ctx, stmt, dest); err != nil {
+			return statementError(stmt, err)
+		}
+	} else {
+		res, err := r.storage.Exec(r.ctx, stmt)
+		if err != nil {
+			return statementError(stmt, err)
+		}
+
+		if len(capCols) == 1 {
+			if id, ok := lastInsertID(res, dest, capCols[0], r.storage); ok {
+				r.vars.Set(capture[capCols[0]], id)
+
+				return nil
+			}
+		}
+
+		if err := r.selectInserted(row, dest, cols, capCols); err != nil {
+			return err
+		}
+	}
+
+	keys, vals := r.storage.Mapper.ColumnsValues(reflect.ValueOf(dest), sqluct.Columns(capCols...))
+	for i, col := range keys {
+		r.vars.Set(capture[col], vals[i])
+	}
+
+	return nil
+}
+
+// lastInsertID returns value of integer column generated by database, for example with auto increment.
+func lastInsertID(res sql.Result, dest interface{}, col string, storage *sqluct.Storage) (interface{}, bool) {
+	_, vals := storage.Mapper.ColumnsValues(reflect.ValueOf(dest), sqluct.Columns(col))
+	if len(vals) != 1 {
+		return nil, false
+	}
+
+	v := reflect.ValueOf(vals[0])
+
+	switch v.Kind() { // nolint:exhaustive // Other kinds are not generated with auto increment.
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return nil, false
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil || id == 0 {
+		return nil, false
+	}
+
+	return reflect.ValueOf(id).Convert(v.Type()).Interface(), true
+}
+
+// selectInserted finds inserted row by provided values to receive values generated by database into dest,
+// it fails if there is no single such row.
+func (r *rowInserter) selectInserted(row, dest interface{}, cols, capCols []string) error {
+	qb := r.storage.SelectStmt(r.table, dest, sqluct.Columns(capCols...)).Limit(2)
+
+	for col, val := range r.storage.WhereEq(row, sqluct.Columns(cols...)) {
+		qb = qb.Where(squirrel.Eq{col: val})
+	}
+
+	found := reflect.New(reflect.SliceOf(reflect.TypeOf(dest).Elem()))
+
+	if err := r.storage.Select(r.ctx, qb, found.Interface()); err != nil {
+		return statementError(qb, err)
+	}
+
+	if n := found.Elem().Len(); n != 1 {
+		return fmt.Errorf("%w: %d rows found by inserted values", errAmbiguousInsert, n)
+	}
+
+	reflect.ValueOf(dest).Elem().Set(found.Elem().Index(0))
+
+	return nil
+}
+
+func statementError(qb sqluct.ToSQL, err error) error {
+	query, args, qbErr := qb.ToSql()
+	if qbErr != nil {
+		return fmt.Errorf("failed to build query: %w", qbErr)
+	}
+
+	return fmt.Errorf("failed to execute %q %v: %w", query, args, err)
+}
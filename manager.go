@@ -16,6 +16,13 @@
 //			},
 //		}
 //
+// Manager is not modified by steps, variables and other data of a scenario are kept in scenario context,
+// so scenarios can run concurrently with godog.Options.Concurrency. Variables of each scenario are seeded
+// with Manager.Vars if it is set, variables populated in a scenario are not added to Manager.Vars.
+//
+// Database operations receive scenario context, so its deadlines and values are propagated.
+// Duration of database operations of a step can be limited with Manager.StepTimeout.
+//
 // Table TableMapper Configuration
 //
 // Table mapper allows customizing decoding string values from godog table cells into Go row structures and back.
@@ -49,6 +56,27 @@
 //
 //   	Given there are no rows in table "my_table" of database "my_db"
 //
+// Delete all rows from all tables of database instance. Tables that refer to other tables with foreign keys
+// are cleaned before referenced tables, foreign keys are received from database metadata for Postgres, MySQL
+// and SQLite, tables of other databases are cleaned in the order of names.
+//
+//   	Given there are no rows in any table of database "my_db"
+//
+// Tables can also be cleaned automatically before each scenario with Instance.Cleanup, scenarios tagged
+// with @keep-db are not cleaned.
+//
+//		dbm.Instances = map[string]dbdog.Instance{
+//			"my_db": {
+//				Storage: storage,
+//				Tables:  tables,
+//				Cleanup: []string{dbdog.AllTables},
+//			},
+//		}
+//
+// Alternatively, scenarios can be isolated with transactions by enabling Manager.TransactionPerScenario.
+// Transaction is started in every instance before scenario and rolled back after it, application code under test
+// can join the transaction of an instance with TxFromContext(ctx, "my_db") in steps that receive scenario context.
+//
 // Populate rows in a database with a gherkin table.
 //
 //	   And these rows are stored in table "my_table" of database "my_db"
@@ -57,6 +85,20 @@
 //		 | 2  | foo-1 | def | 2021-01-02T00:00:00Z | 2021-01-03T00:00:00Z |
 //		 | 3  | foo-2 | hij | 2021-01-03T00:00:00Z | 2021-01-03T00:00:00Z |
 //
+// Cells can contain variables populated in previous steps, such variables are replaced with their values.
+// If variable is not yet populated, its column is excluded from insert and the value generated by database
+// (for example auto incremented ID) is stored in variable. Value is received with INSERT ... RETURNING
+// for postgres, pgx and sqlite drivers. For other drivers, a single integer value is received with LastInsertId,
+// other values with SELECT by values of other columns of inserted row, such SELECT must find exactly one row.
+//
+//	   And these rows are stored in table "my_table" of database "my_db"
+//		 | id   | foo   | bar | created_at           | deleted_at |
+//		 | $id1 | foo-1 | abc | 2021-01-01T00:00:00Z | NULL       |
+//
+//	   And these rows are stored in table "my_another_table" of database "my_db"
+//		 | id | my_table_id | baz |
+//		 | 1  | $id1        | xyz |
+//
 //  Or with an CSV file
 //
 //	   And rows from this file are stored in table "my_table" of database "my_db"
@@ -64,6 +106,83 @@
 //		 path/to/rows.csv
 //		 """
 //
+// Files with .json, .jsonl and .yaml (or .yml) extensions are read as JSON array, JSON Lines or YAML sequence
+// of objects keyed by column names, all objects must have the same keys. Nested objects and arrays are converted
+// to JSON cells, null values to NULL cells. Other files are read as CSV. This is supported by all file steps.
+//
+//		 [
+//		   {"id": 1, "foo": "foo-1", "meta": {"key": "bar"}},
+//		   {"id": 2, "foo": "foo-2", "meta": null}
+//		 ]
+//
+// Paths of files can contain known variables, for example "rows-$id.csv". Relative paths are resolved
+// in Manager.FixturesDir, files are read from Manager.FixturesFS (for example embed.FS) if it is set, or from OS
+// file system otherwise, so that fixtures do not depend on working directory. Errors contain resolved paths.
+//
+//		 dbm.FixturesFS = fixtures // embed.FS with //go:embed testdata
+//		 dbm.FixturesDir = "testdata"
+//
+// Rows are inserted in chunks that fit a limit of bind parameters of database, chunk size can be configured
+// with Manager.InsertChunkSize. Rows are inserted in a single transaction if it takes multiple statements,
+// for example for multiple chunks or for multiple rows with generated values.
+//
+// Rows of files are read and processed in batches of Manager.FileBatchSize (default 1000) rows, so that
+// large files are not loaded in memory at once. This is also the case for assertions of rows from files.
+//
+// Rows from files are stored with faster COPY FROM STDIN in Postgres, PQCopyFrom is used for "postgres" driver
+// (github.com/lib/pq) and PgxCopyFrom for "pgx" driver (github.com/jackc/pgx stdlib). Instance.CopyFrom
+// overrides this, for example for a driver registered with another name.
+//
+// Execute raw SQL statements, for example to update a sequence or call a function.
+// Statements are separated with semicolons, known variables are replaced with their values as SQL literals.
+// Quoted strings, dollar-quoted bodies and comments are kept as is.
+//
+//	   And I execute this SQL in database "my_db":
+//		 """
+//		 ALTER SEQUENCE my_table_id_seq RESTART WITH 100;
+//		 UPDATE my_table SET foo = 'foo-2' WHERE id = $id1;
+//		 """
+//
+// Apply SQL file, for example a dump of baseline data. Statements are split in the same way as for
+// raw SQL step, comments and dollar-quoted bodies are supported. Known variables are replaced with their values.
+// All statements run in one transaction.
+//
+//	   And the SQL file "fixtures/base.sql" is applied to database "my_db"
+//
+// Load fixture with rows of multiple tables from YAML or JSON file, document is an object with arrays of rows
+// keyed by table name. Tables are inserted so that referenced tables precede referencing tables (order of document
+// is kept otherwise), variables populated in one table can be used in another. All tables are stored in one transaction.
+//
+//	   And fixture "fixtures/checkout.yaml" is loaded into database "my_db"
+//
+//		 orders:
+//		   - id: $order
+//		     customer: john
+//		 order_items:
+//		   - order_id: $order
+//		     sku: sku-1
+//
+// Save rows of table to CSV file, for example to make a fixture from the state after a scenario.
+// File can be used in "rows from this file" steps, values are encoded with TableMapper, nulls are written as NULL.
+// Rows are ordered by all columns, values that look like variables or matchers are written with "::string" suffix.
+//
+//	   And rows of table "my_table" of database "my_db" are saved to file "path/to/rows.csv"
+//
+// Assert result of raw SQL query, for example with joins, views or aggregates. Query is executed in a separate
+// step, as gherkin step can not have both doc string and table. Received rows are encoded with TableMapper
+// and compared with gherkin table in the same order. Cells are compared as literal values, matchers are not
+// supported. Not yet populated variables in gherkin table are populated with received values encoded as strings.
+//
+//	   When I query database "my_db" with this SQL:
+//		 """
+//		 SELECT t.foo, COUNT(1) AS cnt FROM my_table t GROUP BY t.foo ORDER BY t.foo
+//		 """
+//
+//	   Then the query result matches:
+//		 | foo   | cnt |
+//		 | foo-1 | 2   |
+//		 | foo-2 | $n  |
+//
 // Assert rows existence in a database.
 //
 // For each row in gherkin table DB is queried to find a row with WHERE condition that includes
@@ -81,6 +200,22 @@
 // If column value represents JSON array or object it is excluded from WHERE condition, value assertion is done
 // by comparing Go value mapped from database row field with Go value mapped from gherkin table cell.
 //
+// Column value can be a matcher instead of exact value, matchers are configured with TableMapper.Matchers.
+// Matcher is converted into SQL condition when possible or checked with Go value mapped from database row field.
+// Default matchers are:
+//   - "<any>" for any value including NULL,
+//   - "<not null>" for any value except NULL,
+//   - "~/^ord-\d+$/" for string representation of value matching regular expression,
+//   - "> 100", ">= 100", "< 100", "<= $limit" for comparison with a value or a variable,
+//   - "!= foo" for inequality, NULL does not match as in SQL,
+//   - "~now±5s" for time within tolerance ("+-" can be used instead of "±").
+//
+// Time values can be relative to current time, e.g. "now", "now-1h", "today", "today+2d",
+// this is supported both for stored and asserted rows.
+//
+// Cell value with "::string" suffix is not checked with matchers, as JSON or as variable,
+// suffix is removed before decoding.
+//
 //	   Then these rows are available in table "my_table" of database "my_db"
 //		 | id   | foo   | bar | created_at           | deleted_at           |
 //		 | $id1 | foo-1 | abc | 2021-01-01T00:00:00Z | NULL                 |
@@ -94,6 +229,11 @@
 //		 path/to/rows.csv
 //		 """
 //
+// Files can be updated with table rows when assertion fails by enabling Manager.UpdateFiles or with
+// DBDOG_UPDATE_FILES=1 environment variable, such step passes with a warning. Columns of file are kept,
+// variables and matchers are replaced with values. Only files of exhaustive ("only") assertions are updated,
+// rows are ordered as in the step or by all columns of file otherwise.
+//
 // It is possible to check table contents exhaustively by adding "only" to step statement. Such assertion will also
 // make sure that total number of rows in database table matches number of rows in gherkin table.
 //
@@ -110,19 +250,79 @@
 //		 path/to/rows.csv
 //		 """
 //
+// If such assertion fails, error contains a diff of gherkin rows and table rows with columns of gherkin table.
+// Rows that are not found in table are marked with "-" and followed by the closest table row marked with "~",
+// mismatched cells of the closest row are wrapped in "*". Unexpected table rows are marked with "+".
+//
+//	     | id | foo      | bar |
+//	   - | 1  | foo-1    | abc |
+//	   ~ | 1  | *my-foo* | abc |
+//	   + | 1  | my-foo   | abc |
+//	   + | 2  | foo-2    | def |
+//
+// Order of rows can be asserted by providing ORDER BY clause, in such case table rows are received in that order
+// and compared with gherkin table rows by position, diff of failed assertion also shows table row at the same position.
+//
+//	   Then only these rows are available in table "my_table" of database "my_db" ordered by "created_at desc"
+//		 | id   | foo   | bar | created_at           |
+//		 | $id3 | foo-2 | hij | 2021-01-03T00:00:00Z |
+//		 | $id2 | foo-1 | def | 2021-01-02T00:00:00Z |
+//		 | $id1 | foo-1 | abc | 2021-01-01T00:00:00Z |
+//
+// Rows existence assertions can be done with a few queries instead of a query per gherkin row by enabling
+// Manager.BulkAssertion, this is helpful for large files. Rows are received in batches by values of a column
+// that has exact value in every gherkin row, or all table rows are received, then rows are matched in memory.
+//
 // Assert no rows exist in a database.
 //
 //	   And no rows are available in table "my_another_table" of database "my_db"
+//
+// Assert rows absence in a database, step fails if any of the rows is found.
+// WHERE condition is built in the same way as for rows existence assertion.
+//
+//	   Then these rows are not available in table "my_table" of database "my_db"
+//		 | id | foo   | bar |
+//		 | 4  | foo-1 | abc |
+//
+// Rows can be also loaded from CSV file.
+//
+//	   Then rows from this file are not available in table "my_table" of database "my_db"
+//		 """
+//		 path/to/rows.csv
+//		 """
+//
+// Assertions of rows existence can be retried until they pass, this is helpful when database is populated
+// asynchronously. Assertion is retried with Manager.EventuallyInterval until time limit is reached,
+// time limit is Manager.EventuallyTimeout unless it is provided in step statement.
+// With Manager.TransactionPerScenario, assertions run in scenario transaction and can not see asynchronous
+// uncommitted writes made outside of it (and committed writes too with REPEATABLE READ or stricter isolation),
+// asynchronous writers should join scenario transaction with TxFromContext.
+//
+//	   Then eventually these rows are available in table "my_table" of database "my_db" within 5s
+//		 | id   | foo   | bar |
+//		 | $id1 | foo-1 | abc |
+//
+//	   Then eventually only rows from this file are available in table "my_table" of database "my_db"
+//		 """
+//		 path/to/rows.csv
+//		 """
+//
+// Assert number of rows in a database table, optionally filtered by column value.
+// Filter value is decoded with TableMapper in the same way as gherkin table cells.
+//
+//	   And there are 3 rows in table "my_table" of database "my_db"
+//	   And there are 2 rows in table "my_table" of database "my_db" where "foo" is "foo-1"
 package dbdog
 
 import (
 	"context"
-	"encoding/csv"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"io/fs"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
@@ -140,92 +340,216 @@ const DefaultDatabase = "default"
 
 // RegisterSteps adds database manager context to test suite.
 func (m *Manager) RegisterSteps(s *godog.ScenarioContext) {
+	m.checkInit()
 	m.registerPrerequisites(s)
+	m.registerEventualAssertions(s)
 	m.registerAssertions(s)
 	s.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
-		if m.Vars == nil {
-			m.Vars = &shared.Vars{}
+		ctx = context.WithValue(ctx, scenarioStateKey{}, m.newScenarioState())
+
+		if err := m.cleanupInstances(ctx, sc); err != nil {
+			return ctx, err
 		}
 
-		m.Vars.Reset()
+		if m.TransactionPerScenario {
+			return m.beginScenarioTx(ctx)
+		}
 
 		return ctx, nil
 	})
+	s.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		return ctx, m.rollbackScenarioTx(ctx)
+	})
 }
 
 func (m *Manager) registerPrerequisites(s *godog.ScenarioContext) {
+	s.Step(`no rows in any table of database "([^"]*)"$`,
+		m.noRowsInAnyTableOfDatabase)
+
+	s.Step(`no rows in any table$`,
+		func(ctx context.Context) error {
+			return m.noRowsInAnyTableOfDatabase(ctx, DefaultDatabase)
+		})
+
 	s.Step(`no rows in table "([^"]*)" of database "([^"]*)"$`,
 		m.noRowsInTableOfDatabase)
 
 	s.Step(`no rows in table "([^"]*)"$`,
-		func(tableName string) error {
-			return m.noRowsInTableOfDatabase(tableName, DefaultDatabase)
+		func(ctx context.Context, tableName string) error {
+			return m.noRowsInTableOfDatabase(ctx, tableName, DefaultDatabase)
 		})
 
 	s.Step(`these rows are stored in table "([^"]*)" of database "([^"]*)"[:]?$`,
-		func(tableName, database string, data *godog.Table) error {
-			return m.theseRowsAreStoredInTableOfDatabase(tableName, database, Rows(data))
+		func(ctx context.Context, tableName, database string, data *godog.Table) error {
+			return m.theseRowsAreStoredInTableOfDatabase(ctx, tableName, database, Rows(data))
 		})
 
 	s.Step(`rows from this file are stored in table "([^"]*)" of database "([^"]*)"[:]?$`,
-		func(tableName, database string, filePath *godog.DocString) error {
-			return m.rowsFromThisFileAreStoredInTableOfDatabase(tableName, database, filePath.Content)
+		func(ctx context.Context, tableName, database string, filePath *godog.DocString) error {
+			return m.rowsFromThisFileAreStoredInTableOfDatabase(ctx, tableName, database, filePath.Content)
 		})
 
 	s.Step(`these rows are stored in table "([^"]*)"[:]?$`,
-		func(tableName string, data *godog.Table) error {
-			return m.theseRowsAreStoredInTableOfDatabase(tableName, DefaultDatabase, Rows(data))
+		func(ctx context.Context, tableName string, data *godog.Table) error {
+			return m.theseRowsAreStoredInTableOfDatabase(ctx, tableName, DefaultDatabase, Rows(data))
 		})
 
 	s.Step(`rows from this file are stored in table "([^"]*)"[:]?$`,
-		func(tableName string, filePath *godog.DocString) error {
-			return m.rowsFromThisFileAreStoredInTableOfDatabase(tableName, DefaultDatabase, filePath.Content)
+		func(ctx context.Context, tableName string, filePath *godog.DocString) error {
+			return m.rowsFromThisFileAreStoredInTableOfDatabase(ctx, tableName, DefaultDatabase, filePath.Content)
+		})
+
+	s.Step(`I execute this SQL in database "([^"]*)"[:]?$`,
+		func(ctx context.Context, database string, query *godog.DocString) error {
+			return m.iExecuteThisSQLInDatabase(ctx, database, query.Content)
+		})
+
+	s.Step(`I execute this SQL[:]?$`,
+		func(ctx context.Context, query *godog.DocString) error {
+			return m.iExecuteThisSQLInDatabase(ctx, DefaultDatabase, query.Content)
+		})
+
+	s.Step(`the SQL file "([^"]*)" is applied to database "([^"]*)"$`,
+		m.theSQLFileIsAppliedToDatabase)
+
+	s.Step(`the SQL file "([^"]*)" is applied$`,
+		func(ctx context.Context, filePath string) error {
+			return m.theSQLFileIsAppliedToDatabase(ctx, filePath, DefaultDatabase)
+		})
+
+	s.Step(`fixture "([^"]*)" is loaded into database "([^"]*)"$`,
+		m.fixtureIsLoadedIntoDatabase)
+
+	s.Step(`fixture "([^"]*)" is loaded$`,
+		func(ctx context.Context, filePath string) error {
+			return m.fixtureIsLoadedIntoDatabase(ctx, filePath, DefaultDatabase)
+		})
+
+	s.Step(`I query database "([^"]*)" with this SQL[:]?$`,
+		func(ctx context.Context, database string, query *godog.DocString) error {
+			return m.iQueryDatabaseWithThisSQL(ctx, database, query.Content)
+		})
+
+	s.Step(`I query database with this SQL[:]?$`,
+		func(ctx context.Context, query *godog.DocString) error {
+			return m.iQueryDatabaseWithThisSQL(ctx, DefaultDatabase, query.Content)
+		})
+
+	s.Step(`rows of table "([^"]*)" of database "([^"]*)" are saved to file "([^"]*)"$`,
+		m.rowsOfTableOfDatabaseAreSavedToFile)
+
+	s.Step(`rows of table "([^"]*)" are saved to file "([^"]*)"$`,
+		func(ctx context.Context, tableName, filePath string) error {
+			return m.rowsOfTableOfDatabaseAreSavedToFile(ctx, tableName, DefaultDatabase, filePath)
 		})
 }
 
 func (m *Manager) registerAssertions(s *godog.ScenarioContext) {
 	s.Step(`only rows from this file are available in table "([^"]*)" of database "([^"]*)"[:]?$`,
-		func(tableName, database string, filePath *godog.DocString) error {
-			return m.onlyRowsFromThisFileAreAvailableInTableOfDatabase(tableName, database, filePath.Content)
+		func(ctx context.Context, tableName, database string, filePath *godog.DocString) error {
+			return m.onlyRowsFromThisFileAreAvailableInTableOfDatabase(ctx, tableName, database, filePath.Content)
 		})
 
 	s.Step(`only these rows are available in table "([^"]*)" of database "([^"]*)"[:]?$`,
-		func(tableName, database string, data *godog.Table) error {
-			return m.onlyTheseRowsAreAvailableInTableOfDatabase(tableName, database, Rows(data))
+		func(ctx context.Context, tableName, database string, data *godog.Table) error {
+			return m.onlyTheseRowsAreAvailableInTableOfDatabase(ctx, tableName, database, Rows(data))
 		})
 
 	s.Step(`only rows from this file are available in table "([^"]*)"[:]?$`,
-		func(tableName string, filePath *godog.DocString) error {
-			return m.onlyRowsFromThisFileAreAvailableInTableOfDatabase(tableName, DefaultDatabase, filePath.Content)
+		func(ctx context.Context, tableName string, filePath *godog.DocString) error {
+			return m.onlyRowsFromThisFileAreAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase, filePath.Content)
 		})
 
 	s.Step(`only these rows are available in table "([^"]*)"[:]?$`,
-		func(tableName string, data *godog.Table) error {
-			return m.onlyTheseRowsAreAvailableInTableOfDatabase(tableName, DefaultDatabase, Rows(data))
+		func(ctx context.Context, tableName string, data *godog.Table) error {
+			return m.onlyTheseRowsAreAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase, Rows(data))
+		})
+
+	s.Step(`only rows from this file are available in table "([^"]*)" of database "([^"]*)" ordered by "([^"]*)"[:]?$`,
+		func(ctx context.Context, tableName, database, orderBy string, filePath *godog.DocString) error {
+			return m.onlyRowsFromThisFileAreAvailableInTableOfDatabaseOrderedBy(ctx, tableName, database, orderBy, filePath.Content)
+		})
+
+	s.Step(`only these rows are available in table "([^"]*)" of database "([^"]*)" ordered by "([^"]*)"[:]?$`,
+		func(ctx context.Context, tableName, database, orderBy string, data *godog.Table) error {
+			return m.onlyTheseRowsAreAvailableInTableOfDatabaseOrderedBy(ctx, tableName, database, orderBy, Rows(data))
+		})
+
+	s.Step(`only rows from this file are available in table "([^"]*)" ordered by "([^"]*)"[:]?$`,
+		func(ctx context.Context, tableName, orderBy string, filePath *godog.DocString) error {
+			return m.onlyRowsFromThisFileAreAvailableInTableOfDatabaseOrderedBy(ctx, tableName, DefaultDatabase, orderBy, filePath.Content)
+		})
+
+	s.Step(`only these rows are available in table "([^"]*)" ordered by "([^"]*)"[:]?$`,
+		func(ctx context.Context, tableName, orderBy string, data *godog.Table) error {
+			return m.onlyTheseRowsAreAvailableInTableOfDatabaseOrderedBy(ctx, tableName, DefaultDatabase, orderBy, Rows(data))
+		})
+
+	s.Step(`the query result matches[:]?$`,
+		func(ctx context.Context, data *godog.Table) error {
+			return m.theQueryResultMatches(ctx, Rows(data))
 		})
 
 	s.Step(`no rows are available in table "([^"]*)" of database "([^"]*)"$`,
 		m.noRowsAreAvailableInTableOfDatabase)
 
 	s.Step(`no rows are available in table "([^"]*)"$`,
-		func(tableName string) error {
-			return m.noRowsAreAvailableInTableOfDatabase(tableName, DefaultDatabase)
+		func(ctx context.Context, tableName string) error {
+			return m.noRowsAreAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase)
 		})
 
 	s.Step(`rows from this file are available in table "([^"]*)" of database "([^"]*)"[:]?$`,
 		m.rowsFromThisFileAreAvailableInTableOfDatabase)
 
+	s.Step(`rows from this file are not available in table "([^"]*)" of database "([^"]*)"[:]?$`,
+		func(ctx context.Context, tableName, database string, filePath *godog.DocString) error {
+			return m.rowsFromThisFileAreNotAvailableInTableOfDatabase(ctx, tableName, database, filePath.Content)
+		})
+
+	s.Step(`these rows are not available in table "([^"]*)" of database "([^"]*)"[:]?$`,
+		func(ctx context.Context, tableName, database string, data *godog.Table) error {
+			return m.theseRowsAreNotAvailableInTableOfDatabase(ctx, tableName, database, Rows(data))
+		})
+
+	s.Step(`rows from this file are not available in table "([^"]*)"[:]?$`,
+		func(ctx context.Context, tableName string, filePath *godog.DocString) error {
+			return m.rowsFromThisFileAreNotAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase, filePath.Content)
+		})
+
+	s.Step(`these rows are not available in table "([^"]*)"[:]?$`,
+		func(ctx context.Context, tableName string, data *godog.Table) error {
+			return m.theseRowsAreNotAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase, Rows(data))
+		})
+
+	s.Step(`(\d+) rows? in table "([^"]*)" of database "([^"]*)"$`,
+		m.rowsInTableOfDatabase)
+
+	s.Step(`(\d+) rows? in table "([^"]*)"$`,
+		func(ctx context.Context, cnt int, tableName string) error {
+			return m.rowsInTableOfDatabase(ctx, cnt, tableName, DefaultDatabase)
+		})
+
+	s.Step(`(\d+) rows? in table "([^"]*)" of database "([^"]*)" where "([^"]*)" is "([^"]*)"$`,
+		m.rowsInTableOfDatabaseWhere)
+
+	s.Step(`(\d+) rows? in table "([^"]*)" where "([^"]*)" is "([^"]*)"$`,
+		func(ctx context.Context, cnt int, tableName, column, value string) error {
+			return m.rowsInTableOfDatabaseWhere(ctx, cnt, tableName, DefaultDatabase, column, value)
+		})
+
 	s.Step(`these rows are available in table "([^"]*)" of database "([^"]*)"[:]?$`,
-		m.theseRowsAreAvailableInTableOfDatabase)
+		func(ctx context.Context, tableName, database string, data *godog.Table) error {
+			return m.theseRowsAreAvailableInTableOfDatabase(ctx, tableName, database, Rows(data))
+		})
 
 	s.Step(`rows from this file are available in table "([^"]*)"[:]?$`,
-		func(tableName string, filePath *godog.DocString) error {
-			return m.rowsFromThisFileAreAvailableInTableOfDatabase(tableName, DefaultDatabase, filePath.Content)
+		func(ctx context.Context, tableName string, filePath *godog.DocString) error {
+			return m.rowsFromThisFileAreAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase, filePath.Content)
 		})
 
 	s.Step(`these rows are available in table "([^"]*)"[:]?$`,
-		func(tableName string, data *godog.Table) error {
-			return m.theseRowsAreAvailableInTableOfDatabase(tableName, DefaultDatabase, Rows(data))
+		func(ctx context.Context, tableName string, data *godog.Table) error {
+			return m.theseRowsAreAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase, Rows(data))
 		})
 }
 
@@ -242,8 +566,57 @@ type Manager struct {
 	TableMapper *TableMapper
 	Instances   map[string]Instance
 
-	// Vars allow sharing vars with other steps.
+	// Vars provide initial variables and variable prefix.
+	//
+	// Each scenario has own vars that are kept in scenario context and seeded with Vars if it is not nil,
+	// so that scenarios can run concurrently. Variables populated in a scenario are not added to Vars.
 	Vars *shared.Vars
+
+	// EventuallyTimeout is a default time limit for "eventually" assertions, default 10s.
+	EventuallyTimeout time.Duration
+
+	// EventuallyInterval is a delay between attempts of "eventually" assertions, default 100ms.
+	EventuallyInterval time.Duration
+
+	// TransactionPerScenario enables isolation of scenarios with transactions.
+	//
+	// Transaction is started in every instance before scenario and rolled back after, all steps run
+	// in that transaction. Transactions are available in scenario context with TxFromContext.
+	// Asynchronous writes made outside of scenario transaction may not be visible to "eventually" assertions.
+	TransactionPerScenario bool
+
+	// BulkAssertion enables assertion of rows with a few queries instead of a query per gherkin row.
+	//
+	// Rows are received in batches of BulkBatchSize (default 1000) values of a column that has exact value
+	// in every gherkin row, or with a single query for all table rows if there is no such column.
+	// Received rows are matched with gherkin rows in memory, Check of conditions is used, but not Predicate.
+	BulkAssertion bool
+	BulkBatchSize int
+
+	// InsertChunkSize is a maximum number of rows in a single INSERT statement.
+	//
+	// By default, it is calculated with number of columns to fit a limit of bind parameters of database.
+	// Rows of multiple chunks are inserted in a single transaction.
+	InsertChunkSize int
+
+	// FileBatchSize is a number of rows that are read from file and processed at once, default 1000.
+	FileBatchSize int
+
+	// FixturesFS is a file system to read files of file steps, for example embed.FS, OS file system is used if nil.
+	// Files can not be saved or updated with FixturesFS.
+	FixturesFS fs.FS
+
+	// FixturesDir is a base directory for relative paths of files in file steps.
+	FixturesDir string
+
+	// UpdateFiles enables rewriting of files with table rows when exhaustive assertion of rows from file fails,
+	// such step passes with a warning. Columns of file are kept, variables and matchers are replaced with values.
+	// Update mode can also be enabled with environment variable DBDOG_UPDATE_FILES.
+	UpdateFiles bool
+
+	// StepTimeout limits duration of database operations of a step, no limit by default.
+	// Each attempt of "eventually" assertion is limited separately, attempt that exceeds the limit is not retried.
+	StepTimeout time.Duration
 }
 
 // Instance provides database instance.
@@ -256,6 +629,15 @@ type Instance struct {
 	// They are executed after `no rows in table` step.
 	// Example: `"my_table": []string{"ALTER SEQUENCE my_table_id_seq RESTART"}`.
 	PostCleanup map[string][]string
+	// Cleanup is a list of tables to delete rows from before each scenario, tables are cleaned in the order of list.
+	// AllTables cleans all tables of instance in foreign key safe order.
+	// Scenarios tagged with KeepDBTag are not cleaned.
+	// Example: `[]string{dbdog.AllTables}`.
+	Cleanup []string
+	// CopyFrom stores rows from files with COPY FROM STDIN, values are decoded with TableMapper.
+	// By default, PQCopyFrom is used for "postgres" driver and PgxCopyFrom for "pgx" driver,
+	// rows are inserted for other drivers. Example: `dbdog.PgxCopyFrom(storage)`.
+	CopyFrom CopyFunc
 }
 
 // RegisterJSONTypes registers types of provided values to unmarshal as JSON when decoding from string.
@@ -275,7 +657,10 @@ func (m *Manager) RegisterJSONTypes(values ...interface{}) {
 	}
 }
 
-func (m *Manager) noRowsInTableOfDatabase(tableName, dbName string) error {
+func (m *Manager) noRowsInTableOfDatabase(ctx context.Context, tableName, dbName string) error {
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
 	instance, ok := m.Instances[dbName]
 	if !ok {
 		return fmt.Errorf("%w %s", errUnknownDatabase, dbName)
@@ -286,9 +671,14 @@ func (m *Manager) noRowsInTableOfDatabase(tableName, dbName string) error {
 		return fmt.Errorf("%w %s in database %s", errUnknownTable, tableName, dbName)
 	}
 
+	return deleteRows(m.instanceContext(ctx, dbName), instance, tableName, dbName)
+}
+
+// deleteRows deletes all rows from table and executes post cleanup statements.
+func deleteRows(ctx context.Context, instance Instance, tableName, dbName string) error {
 	// Deleting from table
 	_, err := instance.Storage.Exec(
-		context.Background(),
+		ctx,
 		instance.Storage.DeleteStmt(tableName),
 	)
 	if err != nil {
@@ -298,7 +688,7 @@ func (m *Manager) noRowsInTableOfDatabase(tableName, dbName string) error {
 	if instance.PostCleanup != nil {
 		for _, statement := range instance.PostCleanup[tableName] {
 			_, err := instance.Storage.Exec(
-				context.Background(),
+				ctx,
 				sqluct.StringStatement(statement),
 			)
 			if err != nil {
@@ -311,35 +701,6 @@ func (m *Manager) noRowsInTableOfDatabase(tableName, dbName string) error {
 	return err
 }
 
-var errMissingFileName = errors.New("missing file name")
-
-func loadTableFromFile(filePath string) (rows [][]string, err error) {
-	if filePath == "" {
-		return nil, errMissingFileName
-	}
-
-	f, err := os.Open(filePath) // nolint:gosec // Intended file inclusion.
-	if err != nil {
-		return nil, err
-	}
-
-	defer func() { // nolint:gosec // False positive: G307: Deferring unsafe method "Close" on type "*os.File" (gosec)
-		clErr := f.Close()
-		if clErr != nil && err == nil {
-			err = clErr
-		}
-	}()
-
-	c := csv.NewReader(f)
-
-	rows, err = c.ReadAll()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV: %w", err)
-	}
-
-	return rows, nil
-}
-
 // Rows converts godog table to a nested slice of strings.
 func Rows(data *godog.Table) [][]string {
 	d := make([][]string, 0, len(data.Rows))
@@ -357,82 +718,160 @@ func Rows(data *godog.Table) [][]string {
 	return d
 }
 
-func (m *Manager) rowsFromThisFileAreStoredInTableOfDatabase(tableName, dbName string, filePath string) error {
-	data, err := loadTableFromFile(filePath)
+func (m *Manager) rowsFromThisFileAreStoredInTableOfDatabase(ctx context.Context, tableName, dbName string, filePath string) (err error) {
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
+	tf, err := m.openFile(ctx, filePath)
 	if err != nil {
-		return fmt.Errorf("failed to load rows from file: %w", err)
+		return err
 	}
 
-	return m.theseRowsAreStoredInTableOfDatabase(tableName, dbName, data)
-}
+	defer closeFile(tf, &err)
 
-func (m *Manager) theseRowsAreStoredInTableOfDatabase(tableName, dbName string, data [][]string) error {
-	instance, ok := m.Instances[dbName]
-	if !ok {
-		return fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+	t, err := m.makeTableQueryOf(ctx, tableName, dbName, nil, tf)
+	if err != nil {
+		return err
 	}
 
-	row, ok := instance.Tables[tableName]
-	if !ok {
-		return fmt.Errorf("%w %s in database %s", errUnknownTable, tableName, dbName)
+	copyFrom := m.Instances[dbName].CopyFrom
+	if copyFrom == nil {
+		copyFrom = defaultCopyFrom(t.storage)
 	}
 
-	m.checkInit()
+	return m.storeRows(t, copyFrom)
+}
 
-	// Reading rows.
-	rows, err := m.TableMapper.SliceFromTable(data, row)
+func (m *Manager) theseRowsAreStoredInTableOfDatabase(ctx context.Context, tableName, dbName string, data [][]string) error {
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
+	t, err := m.makeTableQuery(ctx, tableName, dbName, data)
 	if err != nil {
-		return fmt.Errorf("failed to map rows table: %w", err)
+		return err
 	}
 
-	colNames := data[0]
+	return m.storeRows(t, nil)
+}
 
-	storage := instance.Storage
-	stmt := storage.InsertStmt(tableName, rows, sqluct.Columns(colNames...))
+// storeRows inserts rows of table query, batches of rows without pending variables are stored
+// with copyFrom if it is not nil.
+func (m *Manager) storeRows(t *tableQuery, copyFrom CopyFunc) error {
+	ri, err := t.newRowInserter()
+	if err != nil {
+		return err
+	}
 
-	// Inserting rows.
-	_, err = storage.Exec(context.Background(), stmt)
+	ri.copyFrom = copyFrom
 
+	single, err := ri.singleStatement()
 	if err != nil {
-		query, args, toSQLErr := stmt.ToSql()
-		if toSQLErr != nil {
-			return toSQLErr
-		}
+		return err
+	}
 
-		return fmt.Errorf("failed to insert rows %q, %v: %w", query, args, err)
+	if single {
+		return ri.insert()
 	}
 
-	return err
+	// Multiple statements are executed in a single transaction.
+	return inConnTx(t.ctx, t.storage, func(ctx context.Context) error {
+		t.ctx = ctx
+
+		return ri.insert()
+	})
+}
+
+func (m *Manager) onlyRowsFromThisFileAreAvailableInTableOfDatabase(ctx context.Context, tableName, dbName string, filePath string) error {
+	return m.assertFile(ctx, tableName, dbName, filePath, "", true, func(tf *tableFile) error {
+		return m.assertRows(ctx, tableName, dbName, nil, tf, true)
+	})
+}
+
+func (m *Manager) onlyTheseRowsAreAvailableInTableOfDatabase(ctx context.Context, tableName, dbName string, data [][]string) error {
+	return m.assertRows(ctx, tableName, dbName, data, nil, true)
+}
+
+func (m *Manager) noRowsAreAvailableInTableOfDatabase(ctx context.Context, tableName, dbName string) error {
+	return m.assertRows(ctx, tableName, dbName, nil, nil, true)
+}
+
+func (m *Manager) rowsFromThisFileAreAvailableInTableOfDatabase(ctx context.Context, tableName, dbName string, filePath string) error {
+	return m.assertFile(ctx, tableName, dbName, filePath, "", false, func(tf *tableFile) error {
+		return m.assertRows(ctx, tableName, dbName, nil, tf, false)
+	})
+}
+
+func (m *Manager) theseRowsAreAvailableInTableOfDatabase(ctx context.Context, tableName, dbName string, data [][]string) error {
+	return m.assertRows(ctx, tableName, dbName, data, nil, false)
+}
+
+func (m *Manager) onlyRowsFromThisFileAreAvailableInTableOfDatabaseOrderedBy(
+	ctx context.Context, tableName, dbName, orderBy string, filePath string,
+) error {
+	return m.assertFile(ctx, tableName, dbName, filePath, orderBy, true, func(tf *tableFile) error {
+		return m.assertOrderedRows(ctx, tableName, dbName, nil, tf, orderBy)
+	})
 }
 
-func (m *Manager) onlyRowsFromThisFileAreAvailableInTableOfDatabase(tableName, dbName string, filePath string) error {
-	data, err := loadTableFromFile(filePath)
+func (m *Manager) onlyTheseRowsAreAvailableInTableOfDatabaseOrderedBy(ctx context.Context, tableName, dbName, orderBy string, data [][]string) error {
+	return m.assertOrderedRows(ctx, tableName, dbName, data, nil, orderBy)
+}
+
+func (m *Manager) rowsFromThisFileAreNotAvailableInTableOfDatabase(ctx context.Context, tableName, dbName string, filePath string) (err error) {
+	tf, err := m.openFile(ctx, filePath)
 	if err != nil {
-		return fmt.Errorf("failed to load rows from file: %w", err)
+		return err
 	}
 
-	return m.assertRows(tableName, dbName, data, true)
+	defer closeFile(tf, &err)
+
+	return m.assertAbsentRows(ctx, tableName, dbName, nil, tf)
 }
 
-func (m *Manager) onlyTheseRowsAreAvailableInTableOfDatabase(tableName, dbName string, data [][]string) error {
-	return m.assertRows(tableName, dbName, data, true)
+func (m *Manager) theseRowsAreNotAvailableInTableOfDatabase(ctx context.Context, tableName, dbName string, data [][]string) error {
+	return m.assertAbsentRows(ctx, tableName, dbName, data, nil)
 }
 
-func (m *Manager) noRowsAreAvailableInTableOfDatabase(tableName, dbName string) error {
-	return m.assertRows(tableName, dbName, nil, true)
+func (m *Manager) rowsInTableOfDatabase(ctx context.Context, cnt int, tableName, dbName string) error {
+	return m.countRows(ctx, tableName, dbName, cnt, nil)
 }
 
-func (m *Manager) rowsFromThisFileAreAvailableInTableOfDatabase(tableName, dbName string, filePath string) error {
-	data, err := loadTableFromFile(filePath)
+func (m *Manager) rowsInTableOfDatabaseWhere(ctx context.Context, cnt int, tableName, dbName, column, value string) error {
+	return m.countRows(ctx, tableName, dbName, cnt, [][]string{{column}, {value}})
+}
+
+func (m *Manager) countRows(ctx context.Context, tableName, dbName string, expected int, filter [][]string) (err error) {
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
+	t, err := m.makeTableQuery(ctx, tableName, dbName, nil)
 	if err != nil {
-		return fmt.Errorf("failed to load rows from file: %w", err)
+		return err
 	}
 
-	return m.assertRows(tableName, dbName, data, false)
-}
+	defer func() {
+		// Expose table contents to simplify test debugging.
+		if err != nil {
+			err = t.exposeContents(err)
+		}
+	}()
 
-func (m *Manager) theseRowsAreAvailableInTableOfDatabase(tableName, dbName string, data [][]string) error {
-	return m.assertRows(tableName, dbName, data, false)
+	where, err := t.filter(filter)
+	if err != nil {
+		return err
+	}
+
+	cnt, err := t.count(where)
+	if err != nil {
+		return err
+	}
+
+	if cnt != expected {
+		return fmt.Errorf("%w: %d expected, %d found",
+			errInvalidNumberOfRows, expected, cnt)
+	}
+
+	return nil
 }
 
 type testingT struct {
@@ -444,6 +883,7 @@ func (t *testingT) Errorf(format string, args ...interface{}) {
 }
 
 type tableQuery struct {
+	ctx           context.Context
 	storage       *sqluct.Storage
 	mapper        *TableMapper
 	table         string
@@ -453,11 +893,20 @@ type tableQuery struct {
 	skipWhereCols []string
 	postCheck     []string
 	vars          *shared.Vars
+	orderBy       string
+	conditions    map[string]Condition
+	bulkBatchSize int
+	chunkSize     int
+	file          *tableFile
 }
 
 func (t *tableQuery) exposeContents(err error) error {
 	qb := t.storage.SelectStmt(t.table, t.row).Limit(50)
 
+	if t.orderBy != "" {
+		qb = qb.OrderBy(t.orderBy)
+	}
+
 	var colNames []string
 
 	if t.data != nil {
@@ -474,35 +923,91 @@ func (t *tableQuery) exposeContents(err error) error {
 	return err
 }
 
+// expose adds diff of rows for exhaustive assertion or table contents otherwise to error.
+func (t *tableQuery) expose(err error, exhaustiveList bool) error {
+	if exhaustiveList {
+		return t.exposeDiff(err)
+	}
+
+	return t.exposeContents(err)
+}
+
 func (t *tableQuery) checkCount() error {
-	dataCnt := 0
+	dataCnt, err := t.rowsCount()
+	if err != nil {
+		return err
+	}
 
-	if t.data != nil {
-		dataCnt = len(t.data) - 1
+	cnt, err := t.count(nil)
+	if err != nil {
+		return err
+	}
+
+	if cnt != dataCnt {
+		return fmt.Errorf("%w: %d expected, %d found",
+			errInvalidNumberOfRows, dataCnt, cnt)
 	}
 
+	return nil
+}
+
+// count returns number of rows in table that match optional condition.
+func (t *tableQuery) count(where squirrel.Eq) (int, error) {
 	qb := t.storage.QueryBuilder().
 		Select("COUNT(1) AS c").
 		From(t.table)
 
+	if len(where) > 0 {
+		qb = qb.Where(where)
+	}
+
 	cnt := struct {
 		Count int `db:"c"`
 	}{}
 
-	err := t.storage.Select(context.Background(), qb, &cnt)
+	err := t.storage.Select(t.ctx, qb, &cnt)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	if cnt.Count != dataCnt {
-		return fmt.Errorf("%w: %d expected, %d found",
-			errInvalidNumberOfRows, dataCnt, cnt.Count)
+	return cnt.Count, nil
+}
+
+// filter decodes single-row gherkin table into WHERE condition.
+func (t *tableQuery) filter(data [][]string) (squirrel.Eq, error) {
+	if data == nil {
+		return nil, nil
 	}
 
-	return nil
+	replaces, err := t.varReplaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var where squirrel.Eq
+
+	err = t.mapper.IterateTable(IterateConfig{
+		Data:     data,
+		Item:     t.row,
+		Replaces: replaces,
+		ReceiveRow: func(_ int, row interface{}, colNames []string, _ []string) error {
+			where = t.storage.WhereEq(row, sqluct.Columns(colNames...))
+
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode filter: %w", err)
+	}
+
+	if len(where) != len(data[0]) {
+		return nil, fmt.Errorf("%w %v in table %s", errUnknownColumn, data[0], t.table)
+	}
+
+	return where, nil
 }
 
-func (m *Manager) makeTableQuery(tableName, dbName string, data [][]string) (*tableQuery, error) {
+func (m *Manager) makeTableQuery(ctx context.Context, tableName, dbName string, data [][]string) (*tableQuery, error) {
 	instance, ok := m.Instances[dbName]
 	if !ok {
 		return nil, fmt.Errorf("%w %s", errUnknownDatabase, dbName)
@@ -515,15 +1020,30 @@ func (m *Manager) makeTableQuery(tableName, dbName string, data [][]string) (*ta
 
 	m.checkInit()
 
+	st, err := m.state(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	t := tableQuery{
+		ctx:     m.instanceContext(ctx, dbName),
 		storage: instance.Storage,
 		mapper:  m.TableMapper,
 		table:   tableName,
 		data:    data,
 		row:     row,
-		vars:    m.Vars,
+		vars:    st.vars,
 	}
 
+	if m.BulkAssertion {
+		t.bulkBatchSize = m.BulkBatchSize
+		if t.bulkBatchSize <= 0 {
+			t.bulkBatchSize = defaultBulkBatchSize
+		}
+	}
+
+	t.chunkSize = m.InsertChunkSize
+
 	if t.data != nil {
 		t.colNames = data[0]
 		t.skipWhereCols = make([]string, 0, len(t.colNames))
@@ -533,7 +1053,8 @@ func (m *Manager) makeTableQuery(tableName, dbName string, data [][]string) (*ta
 	return &t, nil
 }
 
-func (t *tableQuery) receiveRow(index int, row interface{}, _ []string, rawValues []string) error {
+// rowQuery builds a query to find rows with column values of decoded gherkin row.
+func (t *tableQuery) rowQuery(row interface{}, conditions map[string]resolvedCondition) squirrel.SelectBuilder {
 	qb := t.storage.QueryBuilder().
 		Select(t.colNames...).
 		From(t.table)
@@ -554,9 +1075,34 @@ func (t *tableQuery) receiveRow(index int, row interface{}, _ []string, rawValue
 		qb = qb.Where(squirrel.Eq{col: eq[col]})
 	}
 
-	dest := reflect.New(reflect.TypeOf(row).Elem()).Interface()
+	for _, col := range t.colNames {
+		if c, ok := conditions[col]; ok && c.Predicate != nil {
+			if p := c.Predicate(col, c.operand); p != nil {
+				qb = qb.Where(p)
+			}
+		}
+	}
+
+	return qb
+}
+
+func (t *tableQuery) receiveRow(index int, row interface{}, _ []string, rawValues []string) error {
+	conditions, err := t.resolveConditions()
+	if err != nil {
+		return fmt.Errorf("row %d: %w", index, err)
+	}
+
+	qb := t.rowQuery(row, conditions)
+
+	var dest interface{}
+
+	if len(conditions) == 0 {
+		dest = reflect.New(reflect.TypeOf(row).Elem()).Interface()
+		err = t.storage.Select(t.ctx, qb, dest)
+	} else {
+		dest, err = t.selectMatching(qb, reflect.TypeOf(row).Elem(), conditions)
+	}
 
-	err := t.storage.Select(context.Background(), qb, dest)
 	if err != nil {
 		query, args, qbErr := qb.ToSql()
 		if qbErr != nil {
@@ -577,6 +1123,82 @@ func (t *tableQuery) receiveRow(index int, row interface{}, _ []string, rawValue
 		rawValues)
 }
 
+// selectMatching queries rows and returns pointer to the first one that passes checks of conditions.
+func (t *tableQuery) selectMatching(qb squirrel.SelectBuilder, it reflect.Type, conditions map[string]resolvedCondition) (interface{}, error) {
+	dest := reflect.New(reflect.SliceOf(it))
+
+	if err := t.storage.Select(t.ctx, qb, dest.Interface()); err != nil {
+		return nil, err
+	}
+
+	colOption := sqluct.Columns(t.colNames...)
+	found := dest.Elem()
+	err := sql.ErrNoRows
+
+	for i := 0; i < found.Len(); i++ {
+		argsRcv := combine(t.storage.Mapper.ColumnsValues(found.Index(i), colOption))
+
+		if err = t.checkConditions(conditions, argsRcv); err == nil {
+			return found.Index(i).Addr().Interface(), nil
+		}
+	}
+
+	return nil, err
+}
+
+// receiveAbsentRow fails if there is a row with column values of decoded gherkin row.
+func (t *tableQuery) receiveAbsentRow(index int, row interface{}, _ []string, _ []string) error {
+	conditions, err := t.resolveConditions()
+	if err != nil {
+		return fmt.Errorf("row %d: %w", index, err)
+	}
+
+	qb := t.rowQuery(row, conditions)
+
+	dest := reflect.New(reflect.SliceOf(reflect.TypeOf(row).Elem()))
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	err = t.storage.Select(t.ctx, qb, dest.Interface())
+	if err != nil {
+		return fmt.Errorf("failed to query row %d (%+v) with %q %v: %w", index, row, query, args, err)
+	}
+
+	colOption := sqluct.Columns(t.colNames...)
+
+	pc := t.postCheck
+	t.postCheck = t.postCheck[:0]
+
+	argsExp := combine(t.storage.Mapper.ColumnsValues(reflect.ValueOf(row), colOption))
+	found := dest.Elem()
+
+	for i := 0; i < found.Len(); i++ {
+		argsRcv := combine(t.storage.Mapper.ColumnsValues(found.Index(i), colOption))
+		matched := t.checkConditions(conditions, argsRcv) == nil
+
+		for _, col := range pc {
+			if !matched {
+				break
+			}
+
+			if !assert.ObjectsAreEqual(indirect(argsExp[col]), indirect(argsRcv[col])) {
+				matched = false
+
+				break
+			}
+		}
+
+		if matched {
+			return fmt.Errorf("%w: row %d (%+v) found with %q %v", errUnexpectedRow, index, row, query, args)
+		}
+	}
+
+	return nil
+}
+
 func combine(keys []string, vals []interface{}) map[string]interface{} {
 	m := make(map[string]interface{}, len(keys))
 	for i, k := range keys {
@@ -587,6 +1209,19 @@ func combine(keys []string, vals []interface{}) map[string]interface{} {
 }
 
 func (t *tableQuery) skipDecode(column, value string) bool {
+	// If value is recognized by a matcher, it is removed from decoding and WHERE condition
+	// and checked with a custom condition.
+	if c, ok := t.mapper.match(value); ok {
+		if t.conditions == nil {
+			t.conditions = make(map[string]Condition)
+		}
+
+		t.conditions[column] = c
+		t.skipWhereCols = append(t.skipWhereCols, column)
+
+		return true
+	}
+
 	// Databases do not provide JSON equality conditions in general,
 	// so if value looks like a non-scalar JSON it is removed from WHERE condition and checked for equality
 	// using Go values during post processing.
@@ -599,7 +1234,7 @@ func (t *tableQuery) skipDecode(column, value string) bool {
 
 	// If value looks like a variable name and does not have an associated value yet,
 	// it is removed from decoding and WHERE condition.
-	if t.vars.IsVar(value) {
+	if isVar(t.vars, value) {
 		if _, found := t.vars.Get(value); found {
 			return false
 		}
@@ -612,7 +1247,8 @@ func (t *tableQuery) skipDecode(column, value string) bool {
 	return false
 }
 
-func (t *tableQuery) makeReplaces(onSetErr *error) (map[string]string, error) {
+// varReplaces encodes values of known variables.
+func (t *tableQuery) varReplaces() (map[string]string, error) {
 	replaces := make(map[string]string)
 
 	if vars := t.vars.GetAll(); len(vars) > 0 {
@@ -628,6 +1264,15 @@ func (t *tableQuery) makeReplaces(onSetErr *error) (map[string]string, error) {
 		}
 	}
 
+	return replaces, nil
+}
+
+func (t *tableQuery) makeReplaces(onSetErr *error) (map[string]string, error) {
+	replaces, err := t.varReplaces()
+	if err != nil {
+		return nil, err
+	}
+
 	t.vars.OnSet(func(key string, val interface{}) {
 		s, err := t.mapper.Encode(val)
 		if err != nil {
@@ -640,8 +1285,13 @@ func (t *tableQuery) makeReplaces(onSetErr *error) (map[string]string, error) {
 	return replaces, nil
 }
 
-func (m *Manager) assertRows(tableName, dbName string, data [][]string, exhaustiveList bool) (err error) {
-	t, err := m.makeTableQuery(tableName, dbName, data)
+func (m *Manager) assertRows(
+	ctx context.Context, tableName, dbName string, data [][]string, tf *tableFile, exhaustiveList bool,
+) (err error) {
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
+	t, err := m.makeTableQueryOf(ctx, tableName, dbName, data, tf)
 	if err != nil {
 		return err
 	}
@@ -649,10 +1299,14 @@ func (m *Manager) assertRows(tableName, dbName string, data [][]string, exhausti
 	defer func() {
 		// Expose table contents to simplify test debugging.
 		if err != nil {
-			err = t.exposeContents(err)
+			err = t.expose(err, exhaustiveList)
 		}
 	}()
 
+	return t.assertRows(exhaustiveList)
+}
+
+func (t *tableQuery) assertRows(exhaustiveList bool) (err error) {
 	if exhaustiveList {
 		err = t.checkCount()
 		if err != nil {
@@ -660,10 +1314,14 @@ func (m *Manager) assertRows(tableName, dbName string, data [][]string, exhausti
 		}
 	}
 
-	if data == nil {
+	if t.data == nil {
 		return nil
 	}
 
+	if t.bulkBatchSize > 0 {
+		return t.assertRowsBulk()
+	}
+
 	var onSetErr error
 
 	replaces, err := t.makeReplaces(&onSetErr)
@@ -672,12 +1330,88 @@ func (m *Manager) assertRows(tableName, dbName string, data [][]string, exhausti
 	}
 
 	// Iterating rows.
-	err = m.TableMapper.IterateTable(IterateConfig{
-		Data:       data,
-		Item:       t.row,
-		SkipDecode: t.skipDecode,
-		Replaces:   replaces,
-		ReceiveRow: t.receiveRow,
+	err = t.eachBatch(func(offset int, data [][]string) error {
+		return t.mapper.IterateTable(IterateConfig{
+			Data:       data,
+			Item:       t.row,
+			SkipDecode: t.skipDecode,
+			Replaces:   replaces,
+			ReceiveRow: withOffset(offset, t.receiveRow),
+		})
+	})
+
+	if err == nil && onSetErr != nil {
+		err = onSetErr
+	}
+
+	return err
+}
+
+func (m *Manager) assertOrderedRows(
+	ctx context.Context, tableName, dbName string, data [][]string, tf *tableFile, orderBy string,
+) (err error) {
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
+	t, err := m.makeTableQueryOf(ctx, tableName, dbName, data, tf)
+	if err != nil {
+		return err
+	}
+
+	t.orderBy = orderBy
+
+	defer func() {
+		// Expose diff of rows to simplify test debugging.
+		if err != nil {
+			err = t.expose(err, true)
+		}
+	}()
+
+	it, err := itemType(t.row)
+	if err != nil {
+		return err
+	}
+
+	qb := t.storage.QueryBuilder().
+		Select(t.colNames...).
+		From(t.table).
+		OrderBy(orderBy)
+
+	received := reflect.New(reflect.SliceOf(it))
+
+	if err := t.storage.Select(t.ctx, qb, received.Interface()); err != nil {
+		return statementError(qb, err)
+	}
+
+	rcv := received.Elem()
+
+	cnt, err := t.rowsCount()
+	if err != nil {
+		return err
+	}
+
+	if rcv.Len() != cnt {
+		return fmt.Errorf("%w: %d expected, %d found",
+			errInvalidNumberOfRows, cnt, rcv.Len())
+	}
+
+	var onSetErr error
+
+	replaces, err := t.makeReplaces(&onSetErr)
+	if err != nil {
+		return err
+	}
+
+	err = t.eachBatch(func(offset int, data [][]string) error {
+		return t.mapper.IterateTable(IterateConfig{
+			Data:       data,
+			Item:       t.row,
+			SkipDecode: t.skipDecode,
+			Replaces:   replaces,
+			ReceiveRow: withOffset(offset, func(index int, row interface{}, colNames []string, rawValues []string) error {
+				return t.compareRow(index, row, rcv.Index(index), rawValues)
+			}),
+		})
 	})
 
 	if err == nil && onSetErr != nil {
@@ -687,9 +1421,101 @@ func (m *Manager) assertRows(tableName, dbName string, data [][]string, exhausti
 	return err
 }
 
+// compareRow checks that received row matches decoded gherkin row.
+func (t *tableQuery) compareRow(index int, row interface{}, received reflect.Value, rawValues []string) error {
+	conditions, err := t.resolveConditions()
+	if err != nil {
+		return fmt.Errorf("row %d: %w", index, err)
+	}
+
+	colOption := sqluct.Columns(t.colNames...)
+	argsExp := combine(t.storage.Mapper.ColumnsValues(reflect.ValueOf(row), colOption))
+	argsRcv := combine(t.storage.Mapper.ColumnsValues(received, colOption))
+
+	skip := t.skipWhereCols
+	t.skipWhereCols = t.skipWhereCols[:0]
+
+	pc := t.postCheck
+	t.postCheck = t.postCheck[:0]
+
+	for _, col := range t.colNames {
+		skipped := false
+
+		for _, sk := range skip {
+			if sk == col {
+				skipped = true
+
+				break
+			}
+		}
+
+		if skipped {
+			continue
+		}
+
+		if exp, rcv := indirect(argsExp[col]), indirect(argsRcv[col]); !valuesEqual(exp, rcv) {
+			return fmt.Errorf("%w at row %d, column %s (%#v expected, %#v received)",
+				errRowMismatch, index, col, exp, rcv)
+		}
+	}
+
+	if err := t.checkConditions(conditions, argsRcv); err != nil {
+		return fmt.Errorf("%w at row %d, %v", errRowMismatch, index, err)
+	}
+
+	if err := t.doPostCheck(t.colNames, pc, argsExp, argsRcv, rawValues); err != nil {
+		return fmt.Errorf("row %d: %w", index, err)
+	}
+
+	return nil
+}
+
+// valuesEqual compares Go values of row fields.
+func valuesEqual(exp, rcv interface{}) bool {
+	if te, ok := exp.(time.Time); ok {
+		if tr, ok := rcv.(time.Time); ok {
+			return te.Equal(tr)
+		}
+	}
+
+	return assert.ObjectsAreEqual(exp, rcv)
+}
+
+func (m *Manager) assertAbsentRows(ctx context.Context, tableName, dbName string, data [][]string, tf *tableFile) (err error) {
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
+	t, err := m.makeTableQueryOf(ctx, tableName, dbName, data, tf)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// Expose table contents to simplify test debugging.
+		if err != nil {
+			err = t.exposeContents(err)
+		}
+	}()
+
+	replaces, err := t.varReplaces()
+	if err != nil {
+		return err
+	}
+
+	return t.eachBatch(func(offset int, data [][]string) error {
+		return m.TableMapper.IterateTable(IterateConfig{
+			Data:       data,
+			Item:       t.row,
+			SkipDecode: t.skipDecode,
+			Replaces:   replaces,
+			ReceiveRow: withOffset(offset, t.receiveAbsentRow),
+		})
+	})
+}
+
 func (t *tableQuery) doPostCheck(colNames []string, postCheck []string, argsExp, argsRcv map[string]interface{}, rawValues []string) error {
 	for i, name := range colNames {
-		if t.vars.IsVar(rawValues[i]) {
+		if isVar(t.vars, rawValues[i]) {
 			t.vars.Set(rawValues[i], argsRcv[name])
 		}
 
@@ -723,9 +1549,17 @@ func (t *tableQuery) doPostCheck(colNames []string, postCheck []string, argsExp,
 func indirect(v interface{}) interface{} {
 	rv := reflect.ValueOf(v)
 	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+
 		rv = rv.Elem()
 	}
 
+	if !rv.IsValid() {
+		return nil
+	}
+
 	return rv.Interface()
 }
 
@@ -736,7 +1570,13 @@ func (m *Manager) checkInit() {
 }
 
 // ParseTime tries to parse time in multiple formats.
+//
+// Relative time expressions are also supported, see ParseRelativeTime.
 func ParseTime(s string, formats ...string) (time.Time, error) {
+	if t, ok, err := ParseRelativeTime(s, time.Now()); ok {
+		return t, err
+	}
+
 	if len(formats) == 0 {
 		formats = []string{
 			time.RFC3339Nano,
@@ -761,6 +1601,53 @@ func ParseTime(s string, formats ...string) (time.Time, error) {
 	return t, err
 }
 
+// ParseRelativeTime parses time expression relative to current time.
+//
+// Expression starts with "now" (current time) or "today" (beginning of current day in UTC) and can have
+// an offset in a form of Go duration or a number of days, e.g. "now-1h", "now+30s", "today-2d".
+//
+// It returns false if expression is not relative.
+func ParseRelativeTime(s string, now time.Time) (time.Time, bool, error) {
+	var base time.Time
+
+	now = now.UTC()
+
+	switch {
+	case strings.HasPrefix(s, "now"):
+		base = now
+		s = s[3:]
+	case strings.HasPrefix(s, "today"):
+		base = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		s = s[5:]
+	default:
+		return time.Time{}, false, nil
+	}
+
+	if s == "" {
+		return base, true, nil
+	}
+
+	if s[0] != '+' && s[0] != '-' {
+		return time.Time{}, false, nil
+	}
+
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return time.Time{}, true, fmt.Errorf("failed to parse relative time offset %q: %w", s, err)
+		}
+
+		return base.AddDate(0, 0, days), true, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, true, fmt.Errorf("failed to parse relative time offset %q: %w", s, err)
+	}
+
+	return base.Add(d), true, nil
+}
+
 // NewTableMapper creates tablestruct.TableMapper with db field decoder.
 func NewTableMapper() *TableMapper {
 	tm := &TableMapper{
@@ -779,6 +1666,8 @@ func NewTableMapper() *TableMapper {
 		return &t, nil
 	}, new(time.Time))
 
+	tm.Matchers = tm.DefaultMatchers()
+
 	tm.Decoder.SetMode(form.ModeExplicit)
 	tm.Decoder.SetTagName("db")
 	form.RegisterSQLNullTypesDecodeFunc(tm.Decoder)
@@ -795,10 +1684,14 @@ var (
 	errInvalidNumberOfRows = errors.New("invalid number of rows in table")
 	errUnknownTable        = errors.New("unknown table")
 	errUnknownDatabase     = errors.New("unknown database")
+	errUnknownColumn       = errors.New("unknown column")
+	errUnexpectedRow       = errors.New("unexpected row in table")
+	errRowMismatch         = errors.New("unexpected row contents")
+	errNoQueryResult       = errors.New("no query result, missing query step")
 )
 
 func (t *tableQuery) queryExistingRows(db *sqluct.Storage, colNames []string, qb squirrel.Sqlizer) (table string, err error) {
-	rows, err := db.Query(context.Background(), qb)
+	rows, err := db.Query(t.ctx, qb)
 	if err != nil {
 		return "", err
 	}
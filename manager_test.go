@@ -2,13 +2,23 @@ package dbdog_test
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Masterminds/squirrel"
 	"github.com/bool64/dbdog"
+	"github.com/bool64/shared"
 	"github.com/bool64/sqluct"
 	"github.com/cucumber/godog"
 	"github.com/jmoiron/sqlx"
@@ -200,10 +210,16 @@ func TestManager_RegisterContext_fail(t *testing.T) {
 
 	createdAt := time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)
 
-	mock.ExpectQuery(`SELECT id, created_at, deleted_at, foo, bar FROM my_table LIMIT 50`).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "deleted_at", "foo", "bar"}).
-			AddRow(1, createdAt, nil, "my-foo", "bar-1").
-			AddRow(2, createdAt, nil, "my-foo", "bar-122"))
+	mock.ExpectQuery(`SELECT id, foo, bar, created_at, deleted_at FROM my_table LIMIT 10001`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "bar", "created_at", "deleted_at"}).
+			AddRow(1, "my-foo", "bar-1", createdAt, nil).
+			AddRow(2, "my-foo", "bar-122", createdAt, nil))
+
+	mock.ExpectQuery(`SELECT id, bar FROM my_table ORDER BY id$`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "bar"}).AddRow(1, "bar-1").AddRow(2, "bar-122"))
+
+	mock.ExpectQuery(`SELECT id, bar FROM my_table ORDER BY id LIMIT 10001`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "bar"}).AddRow(1, "bar-1").AddRow(2, "bar-122"))
 
 	buf := bytes.NewBuffer(nil)
 
@@ -222,11 +238,1614 @@ func TestManager_RegisterContext_fail(t *testing.T) {
 	}
 	status := suite.Run()
 
+	assert.Contains(t, buf.String(), `invalid number of rows in table: 1 expected, 2 found, rows diff:
+  | id | foo      | bar     | created_at                       | deleted_at |
+- | 1  | foo-1    | abc     | 2021-01-01T00:00:00Z             | NULL       |
+~ | 1  | *my-foo* | *bar-1* | *2020-01-01T01:01:01.000000001Z* | NULL       |
++ | 1  | my-foo   | bar-1   | 2020-01-01T01:01:01.000000001Z   | NULL       |
++ | 2  | my-foo   | bar-122 | 2020-01-01T01:01:01.000000001Z   | NULL       |
+`)
+
+	assert.Contains(t, buf.String(), `rows diff:
+  | id  | bar       |
+- | 2   | bar-122   |
+~ | *1* | *bar-1*   |
+- | 1   | bar-1     |
+~ | *2* | *bar-122* |
++ | 1   | bar-1     |
++ | 2   | bar-122   |
+`)
+
+	if status == 0 {
+		t.Fatal(buf.String())
+	}
+}
+
+func TestManager_RegisterContext_count(t *testing.T) {
+	type row struct {
+		ID        int        `db:"id"`
+		Foo       string     `db:"foo"`
+		DeletedAt *time.Time `db:"deleted_at"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table":         new(row),
+				"my_another_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table$`).
+		WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(3))
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table WHERE foo = \$1`).
+		WithArgs("foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(2))
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table WHERE deleted_at IS NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_another_table$`).
+		WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(0))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseCount.feature"},
+			Strict: true,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type meta struct {
+	Key string `json:"key"`
+}
+
+func (m *meta) Scan(src interface{}) error {
+	return json.Unmarshal([]byte(src.(string)), m)
+}
+
+func TestManager_RegisterContext_notAvailable(t *testing.T) {
+	type row struct {
+		ID   int    `db:"id"`
+		Foo  string `db:"foo"`
+		Meta *meta  `db:"meta"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.RegisterJSONTypes(new(meta))
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT id, foo, meta FROM my_table WHERE id = \$1 AND foo = \$2 AND meta IS NULL`).
+		WithArgs(4, "foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "meta"}))
+
+	mock.ExpectQuery(`SELECT id, foo, meta FROM my_table WHERE id = \$1 AND foo = \$2$`).
+		WithArgs(1, "foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "meta"}).
+			AddRow(1, "foo-1", nil).
+			AddRow(1, "foo-1", `{"key":"bar"}`))
+
+	mock.ExpectQuery(`SELECT id, foo, meta FROM my_table WHERE id = \$1 AND foo = \$2$`).
+		WithArgs(1, "foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "meta"}).
+			AddRow(1, "foo-1", `{"key":"bar"}`))
+
+	mock.ExpectQuery(`SELECT id, foo, meta FROM my_table LIMIT 50`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "meta"}).
+			AddRow(1, "foo-1", `{"key":"bar"}`))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseNegative.feature"},
+			Strict: true,
+		},
+	}
+
+	assert.Equal(t, 1, suite.Run(), buf.String())
+	assert.Contains(t, buf.String(), "unexpected row in table: row 0")
+	assert.Contains(t, buf.String(), `
+| id | foo   | meta          |
+| 1  | foo-1 | {"key":"bar"} |
+`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_eventually(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.EventuallyInterval = 5 * time.Millisecond
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	// First attempt fails on count.
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).
+		WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(0))
+
+	// Second attempt passes and populates $id1.
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).
+		WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE foo = \$1`).
+		WithArgs("foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(1, "foo-1"))
+
+	mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE id = \$1 AND foo = \$2`).
+		WithArgs(1, "foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(1, "foo-1"))
+
+	mock.MatchExpectationsInOrder(false)
+
+	for i := 0; i < 3; i++ {
+		mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE id = \$1 AND foo = \$2`).
+			WithArgs(2, "foo-2").
+			WillReturnError(sql.ErrNoRows)
+	}
+
+	mock.ExpectQuery(`SELECT id, foo FROM my_table LIMIT 50`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(1, "foo-1"))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseEventually.feature"},
+			Strict: true,
+		},
+	}
+
+	assert.Equal(t, 1, suite.Run(), buf.String())
+	assert.Contains(t, buf.String(), "assertion did not pass within 10ms")
 	assert.Contains(t, buf.String(), `
-| id | foo    | bar     | created_at                     | deleted_at |
-| 1  | my-foo | bar-1   | 2020-01-01T01:01:01.000000001Z | NULL       |
-| 2  | my-foo | bar-122 | 2020-01-01T01:01:01.000000001Z | NULL       |
+| id | foo   |
+| 1  | foo-1 |
 `)
+}
+
+func TestManager_RegisterContext_insert(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	type childRow struct {
+		ID       int    `db:"id"`
+		ParentID int    `db:"parent_id"`
+		Foo      string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	legacyStorage := sqluct.NewStorage(sqlx.NewDb(db, "sqlmock"))
+	legacyStorage.Format = squirrel.Question
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "postgres")),
+			Tables: map[string]interface{}{
+				"my_table":         new(row),
+				"my_another_table": new(childRow),
+			},
+		},
+		"my_legacy_db": {
+			Storage: legacyStorage,
+			Tables: map[string]interface{}{
+				"my_table":         new(row),
+				"my_another_table": new(childRow),
+			},
+		},
+	}
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`INSERT INTO my_table \(foo\) VALUES \(\$1\) RETURNING id`).
+		WithArgs("foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	mock.ExpectExec(`INSERT INTO my_table \(id,foo\) VALUES \(\$1,\$2\)`).
+		WithArgs(3, "foo-3").
+		WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectQuery(`INSERT INTO my_table \(foo\) VALUES \(\$1\) RETURNING id`).
+		WithArgs("foo-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+	mock.ExpectCommit()
+
+	mock.ExpectExec(`INSERT INTO my_another_table \(id,parent_id,foo\) VALUES \(\$1,\$2,\$3\),\(\$4,\$5,\$6\)`).
+		WithArgs(1, 1, "bar-1", 2, 2, "bar-2").
+		WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectExec(`INSERT INTO my_table \(foo\) VALUES \(\?\)`).
+		WithArgs("foo-4").
+		WillReturnResult(sqlmock.NewResult(4, 1))
+
+	mock.ExpectExec(`INSERT INTO my_another_table \(id,parent_id,foo\) VALUES \(\?,\?,\?\)`).
+		WithArgs(3, 4, "bar-3").
+		WillReturnResult(driver.ResultNoRows)
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseInsert.feature"},
+			Strict: true,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_ordered(t *testing.T) {
+	type row struct {
+		ID        int       `db:"id"`
+		Foo       string    `db:"foo"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+
+	dbm := dbdog.NewManager()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT id, foo, created_at FROM my_table ORDER BY created_at desc`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "created_at"}).
+			AddRow(2, "foo-2", mustParseTime("2021-01-02T00:00:00Z")).
+			AddRow(1, "foo-1", mustParseTime("2021-01-01T00:00:00Z")))
+
+	mock.ExpectQuery(`SELECT id, foo, created_at FROM my_table ORDER BY created_at`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "created_at"}).
+			AddRow(1, "foo-1", mustParseTime("2021-01-01T00:00:00Z")).
+			AddRow(2, "foo-2", mustParseTime("2021-01-02T00:00:00Z")))
+
+	mock.ExpectQuery(`SELECT id, foo, created_at FROM my_table ORDER BY created_at LIMIT 50`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "created_at"}).
+			AddRow(1, "foo-1", mustParseTime("2021-01-01T00:00:00Z")).
+			AddRow(2, "foo-2", mustParseTime("2021-01-02T00:00:00Z")))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseOrdered.feature"},
+			Strict: true,
+		},
+	}
+
+	assert.Equal(t, 1, suite.Run(), buf.String())
+	assert.Contains(t, buf.String(), "unexpected row contents at row 0, column id (2 expected, 1 received)")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_matchers(t *testing.T) {
+	type row struct {
+		ID        int        `db:"id"`
+		Foo       string     `db:"foo"`
+		Amount    float64    `db:"amount"`
+		DeletedAt *time.Time `db:"deleted_at"`
+	}
+
+	dbm := dbdog.NewManager()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	cols := []string{"id", "foo", "amount", "deleted_at"}
+	deletedAt := mustParseTime("2021-01-03T00:00:00Z")
+
+	mock.ExpectQuery(`SELECT id, foo, amount, deleted_at FROM my_table WHERE amount > \$1$`).
+		WithArgs(100.0).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow(3, "bar-3", 200.0, nil).
+			AddRow(1, "foo-1", 150.0, nil))
+
+	mock.ExpectQuery(`SELECT id, foo, amount, deleted_at FROM my_table `+
+		`WHERE id <> \$1 AND foo <> \$2 AND amount <= \$3 AND deleted_at IS NOT NULL$`).
+		WithArgs(1, "foo-1", 100.0).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow(2, "foo-2", 50.0, deletedAt))
+
+	mock.ExpectQuery(`SELECT id, foo, amount FROM my_table WHERE id = \$1$`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "amount"}).
+			AddRow(1, "foo-1", 150.0))
+
+	mock.ExpectQuery(`SELECT id, foo, amount, deleted_at FROM my_table ORDER BY id`).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow(1, "foo-1", 150.0, nil).
+			AddRow(2, "foo-2", 50.0, deletedAt))
+
+	mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE id = \$1$`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).
+			AddRow(1, "foo-1"))
+
+	mock.ExpectQuery(`SELECT id, foo, amount, deleted_at FROM my_table LIMIT 50`).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow(1, "foo-1", 150.0, nil))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseMatchers.feature"},
+			Strict: true,
+		},
+	}
+
+	assert.Equal(t, 1, suite.Run(), buf.String())
+	assert.Contains(t, buf.String(), `column foo: condition failed: "foo-1" does not match ^bar-\d+$`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type timeNear struct {
+	t time.Time
+}
+
+func (n timeNear) Match(v driver.Value) bool {
+	t, ok := v.(time.Time)
+	if !ok {
+		return false
+	}
+
+	return t.Sub(n.t) < time.Minute && n.t.Sub(t) < time.Minute
+}
+
+func TestManager_RegisterContext_relativeTime(t *testing.T) {
+	type row struct {
+		ID        int        `db:"id"`
+		CreatedAt time.Time  `db:"created_at"`
+		DeletedAt *time.Time `db:"deleted_at"`
+	}
+
+	dbm := dbdog.NewManager()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	cols := []string{"id", "created_at", "deleted_at"}
+
+	mock.ExpectExec(`INSERT INTO my_table \(id,created_at,deleted_at\) VALUES \(\$1,\$2,\$3\)`).
+		WithArgs(1, timeNear{now.Add(-time.Hour)}, nil).
+		WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectQuery(`SELECT id, created_at, deleted_at FROM my_table `+
+		`WHERE id = \$1 AND deleted_at IS NULL AND \(created_at >= \$2 AND created_at <= \$3\)`).
+		WithArgs(1, timeNear{now.Add(-time.Hour - time.Minute)}, timeNear{now.Add(-time.Hour + time.Minute)}).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(1, now.Add(-time.Hour), nil))
+
+	mock.ExpectQuery(`SELECT id, created_at, deleted_at FROM my_table WHERE id = \$1 AND created_at > \$2`).
+		WithArgs(1, today.AddDate(0, 0, -1)).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(1, now.Add(-time.Hour), nil))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseTime.feature"},
+			Strict: true,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestParseRelativeTime(t *testing.T) {
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for s, exp := range map[string]time.Time{
+		"now":        now,
+		"now-1h":     now.Add(-time.Hour),
+		"now+30s":    now.Add(30 * time.Second),
+		"today":      time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
+		"today-2d":   time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC),
+		"today+1h":   time.Date(2021, 1, 2, 1, 0, 0, 0, time.UTC),
+		"now-1h30m":  now.Add(-90 * time.Minute),
+		"today+10d":  time.Date(2021, 1, 12, 0, 0, 0, 0, time.UTC),
+		"now+0s":     now,
+		"now-0.5h":   now.Add(-30 * time.Minute),
+		"today-0d":   time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
+		"now-24h":    now.AddDate(0, 0, -1),
+		"today+365d": time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+	} {
+		tm, ok, err := dbdog.ParseRelativeTime(s, now)
+		assert.NoError(t, err, s)
+		assert.True(t, ok, s)
+		assert.Equal(t, exp, tm, s)
+	}
+
+	_, ok, err := dbdog.ParseRelativeTime("2021-01-01", now)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = dbdog.ParseRelativeTime("now-1x", now)
+	assert.Error(t, err)
+	assert.True(t, ok)
+}
+
+func TestManager_RegisterContext_executeSQL(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT id, foo FROM my_table`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(12, "o'foo"))
+
+	mock.ExpectExec(`ALTER SEQUENCE my_table_id_seq RESTART WITH 100`).
+		WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectExec(`UPDATE my_table SET foo = 'it''s; $id1' WHERE id = 12 AND foo = 'o''foo' AND id <> $id1_other`).
+		WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectExec(`SELECT broken`).
+		WillReturnError(errors.New("syntax error"))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseSQL.feature"},
+			Strict: true,
+		},
+	}
+
+	assert.Equal(t, 1, suite.Run(), buf.String())
+	assert.Contains(t, buf.String(), `failed to execute statement "SELECT broken" in db my_db: syntax error`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_queryResult(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE foo = \$1`).
+		WithArgs("foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(10, "foo-1"))
+
+	mock.ExpectQuery(`SELECT t.foo, COUNT\(1\) AS cnt, MAX\(a.bar\) AS bar FROM my_table t .+ WHERE t.id >= 10 GROUP BY`).
+		WillReturnRows(sqlmock.NewRows([]string{"foo", "cnt", "bar"}).
+			AddRow("foo-1", 2, []byte("bar-1")).
+			AddRow("foo-2", 1, nil))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseQuery.feature"},
+			Strict: true,
+		},
+	}
+
+	assert.Equal(t, 1, suite.Run(), buf.String())
+	assert.Contains(t, buf.String(), `unexpected row contents at row 1, column cnt ("2" expected, "1" received)`)
+	assert.Contains(t, buf.String(), `
+| foo   | cnt | bar   |
+| foo-1 | 2   | bar-1 |
+| foo-2 | 1   | NULL  |
+`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_sqlFile(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE foo = $1`).
+		WithArgs("foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(12, "foo-1"))
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`INSERT INTO my_table (id, foo) VALUES (100, 'it''s; fine')`).
+		WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectExec("CREATE FUNCTION my_func() RETURNS int AS $body$\nBEGIN\n    RETURN 1;\nEND;\n$body$ LANGUAGE plpgsql").
+		WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectExec(`UPDATE my_table SET foo = 'foo-2' WHERE id = 12`).
+		WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectCommit()
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseSQLFile.feature"},
+			Strict: true,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_cleanup(t *testing.T) {
+	type row struct {
+		ID int `db:"id"`
+	}
+
+	dbm := dbdog.NewManager()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "postgres")),
+			Tables: map[string]interface{}{
+				"my_table":         new(row),
+				"my_another_table": new(row),
+				"my_third_table":   new(row),
+			},
+			PostCleanup: map[string][]string{
+				"my_table": {"ALTER SEQUENCE my_table_id_seq RESTART"},
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT DISTINCT tc.table_name AS child, ccu.table_name AS parent`).
+		WillReturnRows(sqlmock.NewRows([]string{"child", "parent"}).
+			AddRow("my_table", "my_another_table").
+			AddRow("my_third_table", "my_table").
+			AddRow("my_table", "my_table").
+			AddRow("my_table", "unknown_table"))
+
+	mock.ExpectExec(`DELETE FROM my_third_table`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`DELETE FROM my_table`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`ALTER SEQUENCE my_table_id_seq RESTART`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`DELETE FROM my_another_table`).WillReturnResult(driver.ResultNoRows)
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseCleanup.feature"},
+			Strict: true,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_autoCleanup(t *testing.T) {
+	type row struct {
+		ID int `db:"id"`
+	}
+
+	dbm := dbdog.NewManager()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table":         new(row),
+				"my_another_table": new(row),
+			},
+			PostCleanup: map[string][]string{
+				"my_table": {"ALTER SEQUENCE my_table_id_seq RESTART"},
+			},
+			Cleanup: []string{"my_another_table", "my_table"},
+		},
+	}
+
+	mock.ExpectExec(`DELETE FROM my_another_table`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`DELETE FROM my_table`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`ALTER SEQUENCE my_table_id_seq RESTART`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(0))
+
+	// Scenario with @keep-db tag is not cleaned.
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(0))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseAutoCleanup.feature"},
+			Strict: true,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_transactionPerScenario(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.TransactionPerScenario = true
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`INSERT INTO my_table \(id,foo\) VALUES \(\$1,\$2\)`).
+		WithArgs(1, "foo-1").
+		WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).
+		WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(1))
+
+	mock.ExpectRollback()
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+
+			s.Step(`application joins transaction of database "([^"]*)"$`, func(ctx context.Context, dbName string) error {
+				if dbdog.TxFromContext(ctx, dbName) == nil || sqluct.TxFromContext(ctx) == nil {
+					return errors.New("missing transaction")
+				}
+
+				return nil
+			})
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseTransaction.feature"},
+			Strict: true,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_concurrent(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+
+	// Vars of each scenario are seeded with Manager.Vars.
+	dbm.Vars = &shared.Vars{}
+	dbm.Vars.Set("$foo", "foo-1")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.MatchExpectationsInOrder(false)
+
+	for i := 1; i <= 3; i++ {
+		mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE foo = \$1`).
+			WithArgs(fmt.Sprintf("foo-%d", i)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(i, fmt.Sprintf("foo-%d", i)))
+
+		mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table WHERE id = \$1`).
+			WithArgs(i).
+			WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(1))
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format:      "progress",
+			Output:      buf,
+			Paths:       []string{"DatabaseConcurrent.feature"},
+			Strict:      true,
+			Concurrency: 3,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Variables populated in scenarios are not added to Manager.Vars.
+	assert.Equal(t, map[string]interface{}{"$foo": "foo-1"}, dbm.Vars.GetAll())
+}
+
+func TestManager_RegisterContext_stepTimeout(t *testing.T) {
+	type row struct {
+		ID int `db:"id"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.StepTimeout = 10 * time.Millisecond
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(3))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseTimeout.feature"},
+			Strict: true,
+		},
+	}
+
+	start := time.Now()
+
+	assert.Equal(t, 1, suite.Run(), buf.String())
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	assert.Contains(t, buf.String(), "canceling query due to user request")
+}
+
+func TestManager_RegisterContext_bulk(t *testing.T) {
+	type row struct {
+		ID   int    `db:"id"`
+		Foo  string `db:"foo"`
+		Meta *meta  `db:"meta"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.RegisterJSONTypes(new(meta))
+	dbm.BulkAssertion = true
+	dbm.BulkBatchSize = 1
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).
+		WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(2))
+
+	mock.ExpectQuery(`SELECT id, foo, meta FROM my_table WHERE foo IN \(\$1\)`).
+		WithArgs("foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "meta"}).
+			AddRow(1, "foo-1", `{"key":"bar"}`))
+
+	mock.ExpectQuery(`SELECT id, foo, meta FROM my_table WHERE foo IN \(\$1\)`).
+		WithArgs("foo-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "meta"}).
+			AddRow(2, "foo-2", nil))
+
+	mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE id IN \(\$1\)`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).
+			AddRow(1, "foo-1"))
+
+	mock.ExpectQuery(`SELECT id, foo, meta FROM my_table WHERE id IN \(\$1\)`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "meta"}).
+			AddRow(1, "foo-1", `{"key":"bar"}`))
+
+	mock.ExpectQuery(`SELECT id, foo, meta FROM my_table LIMIT 50`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "meta"}).
+			AddRow(1, "foo-1", `{"key":"bar"}`))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseBulk.feature"},
+			Strict: true,
+		},
+	}
+
+	assert.Equal(t, 1, suite.Run(), buf.String())
+	assert.Contains(t, buf.String(), `unexpected row contents at column meta`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_chunks(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.InsertChunkSize = 2
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`INSERT INTO my_table \(id,foo\) VALUES \(\$1,\$2\),\(\$3,\$4\)`).
+		WithArgs(1, "foo-1", 2, "foo-2").
+		WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectExec(`INSERT INTO my_table \(id,foo\) VALUES \(\$1,\$2\),\(\$3,\$4\)`).
+		WithArgs(3, "foo-3", 4, "foo-4").
+		WillReturnError(errors.New("duplicate key"))
+
+	mock.ExpectRollback()
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseChunks.feature"},
+			Strict: true,
+		},
+	}
+
+	assert.Equal(t, 1, suite.Run(), buf.String())
+	assert.Contains(t, buf.String(), "failed to insert chunk 2 (rows 2-3)")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_copy(t *testing.T) {
+	type row struct {
+		ID        int        `db:"id"`
+		Foo       string     `db:"foo"`
+		Bar       string     `db:"bar"`
+		CreatedAt time.Time  `db:"created_at"`
+		DeletedAt *time.Time `db:"deleted_at"`
+	}
+
+	dbm := dbdog.NewManager()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+
+	// COPY is used by default for postgres driver.
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "postgres")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectBegin()
+
+	prep := mock.ExpectPrepare(`COPY "my_table" ("id", "foo", "bar", "created_at", "deleted_at") FROM STDIN`)
+
+	prep.ExpectExec().
+		WithArgs(1, "foo-1", "abc", mustParseTime("2021-01-01T00:00:00Z"), nil).
+		WillReturnResult(driver.ResultNoRows)
+	prep.ExpectExec().
+		WithArgs(2, "foo-1", "def", mustParseTime("2021-01-02T00:00:00Z"), mustParseTime("2021-01-03T00:00:00Z")).
+		WillReturnResult(driver.ResultNoRows)
+	prep.ExpectExec().
+		WithArgs(3, "foo-2", "hij", mustParseTime("2021-01-03T00:00:00Z"), mustParseTime("2021-01-03T00:00:00Z")).
+		WillReturnResult(driver.ResultNoRows)
+	prep.ExpectExec().
+		WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectCommit()
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseCopy.feature"},
+			Strict: true,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_fileBatches(t *testing.T) {
+	type row struct {
+		ID        int        `db:"id"`
+		Foo       string     `db:"foo"`
+		Bar       string     `db:"bar"`
+		CreatedAt time.Time  `db:"created_at"`
+		DeletedAt *time.Time `db:"deleted_at"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.FileBatchSize = 2
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	// Rows of multiple batches are inserted in a transaction.
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`INSERT INTO my_table \(id,foo,bar,created_at,deleted_at\) VALUES .+`).
+		WithArgs(
+			1, "foo-1", "abc", mustParseTime("2021-01-01T00:00:00Z"), nil,
+			2, "foo-1", "def", mustParseTime("2021-01-02T00:00:00Z"), mustParseTime("2021-01-03T00:00:00Z"),
+			3, "foo-2", "hij", mustParseTime("2021-01-03T00:00:00Z"), mustParseTime("2021-01-03T00:00:00Z"),
+		).
+		WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectCommit()
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(3))
+
+	mock.ExpectQuery(`SELECT .+ FROM my_table WHERE id = \$1 AND foo = \$2 AND bar = \$3 AND created_at = \$4 AND deleted_at IS NULL`).
+		WithArgs(1, "foo-1", "abc", mustParseTime("2021-01-01T00:00:00Z")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "bar", "created_at", "deleted_at"}).
+			AddRow(1, "foo-1", "abc", mustParseTime("2021-01-01T00:00:00Z"), nil))
+
+	mock.ExpectQuery(`SELECT .+ FROM my_table WHERE id = \$1 AND foo = \$2 AND bar = \$3 AND created_at = \$4 AND deleted_at = \$5`).
+		WithArgs(2, "foo-1", "def", mustParseTime("2021-01-02T00:00:00Z"), mustParseTime("2021-01-03T00:00:00Z")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "bar", "created_at", "deleted_at"}).
+			AddRow(2, "foo-1", "def", mustParseTime("2021-01-02T00:00:00Z"), mustParseTime("2021-01-03T00:00:00Z")))
+
+	mock.ExpectQuery(`SELECT .+ FROM my_table WHERE id = \$1 AND foo = \$2 AND bar = \$3 AND created_at = \$4 AND deleted_at = \$5`).
+		WithArgs(3, "foo-2", "hij", mustParseTime("2021-01-03T00:00:00Z"), mustParseTime("2021-01-03T00:00:00Z")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "bar", "created_at", "deleted_at"}).
+			AddRow(3, "foo-2", "hij", mustParseTime("2021-01-03T00:00:00Z"), mustParseTime("2021-01-03T00:00:00Z")))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseFileBatches.feature"},
+			Strict: true,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_updateFiles(t *testing.T) {
+	type row struct {
+		ID  int     `db:"id"`
+		Foo *string `db:"foo"`
+	}
+
+	dir, err := ioutil.TempDir("", "dbdog")
+	assert.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, os.RemoveAll(dir))
+	}()
+
+	golden := filepath.Join(dir, "golden.csv")
+	assert.NoError(t, ioutil.WriteFile(golden, []byte("id,foo\n1,foo-1\n"), 0o600))
+
+	dbm := dbdog.NewManager()
+	dbm.UpdateFiles = true
+	dbm.FixturesDir = dir
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(2))
+
+	mock.ExpectQuery(`SELECT id, foo FROM my_table LIMIT 10001`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(1, "foo-2").AddRow(2, nil))
+
+	mock.ExpectQuery(`^SELECT id, foo FROM my_table ORDER BY id, foo$`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(1, "foo-2").AddRow(2, nil))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseUpdateFiles.feature"},
+			Strict: true,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	updated, err := ioutil.ReadFile(golden)
+	assert.NoError(t, err)
+	assert.Equal(t, "id,foo\n1,foo-2\n2,NULL\n", string(updated))
+}
+
+func TestManager_RegisterContext_save(t *testing.T) {
+	type row struct {
+		ID   int     `db:"id"`
+		Foo  *string `db:"foo"`
+		Meta *meta   `db:"meta"`
+	}
+
+	dir, err := ioutil.TempDir("", "dbdog")
+	assert.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, os.RemoveAll(dir))
+	}()
+
+	dbm := dbdog.NewManager()
+	dbm.FixturesDir = dir
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`^SELECT id, foo, meta FROM my_table ORDER BY id, foo, meta$`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "meta"}).
+			AddRow(1, "foo-1", []byte(`{"key":"bar"}`)).
+			AddRow(2, nil, nil).
+			AddRow(3, "$foo", nil).
+			AddRow(4, "<any>", nil))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseSave.feature"},
+			Strict: true,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	saved, err := ioutil.ReadFile(filepath.Join(dir, "saved.csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, "id,foo,meta\n1,foo-1,\"{\"\"key\"\":\"\"bar\"\"}\"\n2,NULL,NULL\n"+
+		"3,$foo::string,NULL\n4,<any>::string,NULL\n", string(saved))
+}
+
+func TestManager_RegisterContext_formats(t *testing.T) {
+	type row struct {
+		ID   int    `db:"id"`
+		Foo  string `db:"foo"`
+		Meta *meta  `db:"meta"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.RegisterJSONTypes(new(meta))
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		mock.ExpectQuery(`SELECT id, foo, meta FROM my_table WHERE id = \$1 AND foo = \$2$`).
+			WithArgs(1, "foo-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "meta"}).
+				AddRow(1, "foo-1", `{"key":"bar"}`))
+
+		mock.ExpectQuery(`SELECT id, foo, meta FROM my_table WHERE id = \$1 AND foo = \$2 AND meta IS NULL`).
+			WithArgs(2, "foo-2").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "meta"}).
+				AddRow(2, "foo-2", nil))
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseFormats.feature"},
+			Strict: true,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_fixture(t *testing.T) {
+	type order struct {
+		ID       int    `db:"id"`
+		Customer string `db:"customer"`
+	}
+
+	type orderItem struct {
+		ID      int    `db:"id"`
+		OrderID int    `db:"order_id"`
+		SKU     string `db:"sku"`
+	}
+
+	dbm := dbdog.NewManager()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "postgres")),
+			Tables: map[string]interface{}{
+				"orders":      new(order),
+				"order_items": new(orderItem),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT DISTINCT tc.table_name AS child, ccu.table_name AS parent`).
+		WillReturnRows(sqlmock.NewRows([]string{"child", "parent"}).
+			AddRow("order_items", "orders"))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO orders \(customer\) VALUES \(\$1\) RETURNING id`).
+		WithArgs("john").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	mock.ExpectExec(`INSERT INTO order_items \(id,order_id,sku\) VALUES \(\$1,\$2,\$3\),\(\$4,\$5,\$6\)`).
+		WithArgs(1, 10, "sku-1", 2, 10, "sku-2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	mock.ExpectQuery(`SELECT id, order_id, sku FROM order_items WHERE id = \$1 AND order_id = \$2 AND sku = \$3`).
+		WithArgs(1, 10, "sku-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "sku"}).AddRow(1, 10, "sku-1"))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseFixture.feature"},
+			Strict: true,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_fixturesFS(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.FixturesDir = "fixtures"
+	dbm.FixturesFS = fstest.MapFS{
+		"fixtures/update-1.sql": {Data: []byte("UPDATE my_table SET foo = 'bar-1' WHERE id = $id;")},
+		"fixtures/rows-1.csv":   {Data: []byte("id,foo\n1,bar-1\n")},
+	}
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "postgres")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`INSERT INTO my_table \(foo\) VALUES \(\$1\) RETURNING id`).
+		WithArgs("foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE my_table SET foo = 'bar-1' WHERE id = 1`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE id = \$1 AND foo = \$2`).
+		WithArgs(1, "bar-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(1, "bar-1"))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseFixturesFS.feature"},
+			Strict: true,
+		},
+	}
+
+	if suite.Run() != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterContext_emptyObject(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"DatabaseEmptyObject.feature"},
+			Strict: true,
+		},
+	}
+
+	status := suite.Run()
+
+	assert.Contains(t, buf.String(), "row 1: object without keys")
 
 	if status == 0 {
 		t.Fatal(buf.String())
@@ -0,0 +1,413 @@
+package dbdog
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bool64/sqluct"
+)
+
+// Matcher makes a condition from a cell value of assertion table.
+//
+// It returns false if cell value is not recognized by matcher.
+type Matcher func(value string) (Condition, bool)
+
+// Condition defines custom assertion of column value.
+type Condition struct {
+	// Operand is a value to decode into column type and pass to Predicate and Check.
+	// Variable name is replaced with variable value. Empty Operand is not decoded.
+	Operand string
+
+	// Predicate makes SQL condition for a column, nil Predicate means no SQL condition.
+	Predicate func(column string, operand interface{}) squirrel.Sqlizer
+
+	// Check asserts Go value received from database, nil Check means no Go-side check.
+	Check func(operand, received interface{}) error
+}
+
+var (
+	errConditionFailed = errors.New("condition failed")
+	errNotComparable   = errors.New("values are not comparable")
+	errUnknownVariable = errors.New("unknown variable")
+)
+
+// DefaultMatchers returns matchers for these cell values.
+//
+//	<any>          - any value including NULL,
+//	<not null>     - any value except NULL,
+//	~/^ord-\d+$/   - string representation of value matches regular expression,
+//	> 100          - value is greater than operand, also >=, <, <= are available,
+//	!= foo         - value is not equal to operand,
+//	~now±5s        - time value is within tolerance from operand, "+-" can be used instead of "±".
+//
+// Operand of comparison can be a variable, for example "<= $limit".
+func (m *TableMapper) DefaultMatchers() []Matcher {
+	return []Matcher{matchAny, matchNotNull, m.matchRegexp, matchTolerance, matchComparison}
+}
+
+func (m *TableMapper) match(value string) (Condition, bool) {
+	// Values with explicit string type are not matched.
+	if strings.HasSuffix(value, stringSuffix) {
+		return Condition{}, false
+	}
+
+	for _, mt := range m.Matchers {
+		if c, ok := mt(value); ok {
+			return c, true
+		}
+	}
+
+	return Condition{}, false
+}
+
+func matchAny(value string) (Condition, bool) {
+	return Condition{}, value == "<any>"
+}
+
+func matchNotNull(value string) (Condition, bool) {
+	if value != "<not null>" {
+		return Condition{}, false
+	}
+
+	return Condition{
+		Predicate: func(column string, _ interface{}) squirrel.Sqlizer {
+			return squirrel.NotEq{column: nil}
+		},
+		Check: func(_, received interface{}) error {
+			if plainValue(received) == nil {
+				return fmt.Errorf("%w: NULL received", errConditionFailed)
+			}
+
+			return nil
+		},
+	}, true
+}
+
+func (m *TableMapper) matchRegexp(value string) (Condition, bool) {
+	if len(value) < 3 || !strings.HasPrefix(value, "~/") || !strings.HasSuffix(value, "/") {
+		return Condition{}, false
+	}
+
+	re, err := regexp.Compile(value[2 : len(value)-1])
+
+	return Condition{
+		Check: func(_, received interface{}) error {
+			if err != nil {
+				return fmt.Errorf("invalid regular expression: %w", err)
+			}
+
+			s, err := m.Encode(received)
+			if err != nil {
+				return err
+			}
+
+			if !re.MatchString(s) {
+				return fmt.Errorf("%w: %q does not match %s", errConditionFailed, s, re.String())
+			}
+
+			return nil
+		},
+	}, true
+}
+
+var comparison = regexp.MustCompile(`^(>=|<=|!=|>|<)\s+(.+)$`)
+
+func matchComparison(value string) (Condition, bool) {
+	sm := comparison.FindStringSubmatch(value)
+	if sm == nil {
+		return Condition{}, false
+	}
+
+	op := sm[1]
+	c := Condition{Operand: sm[2]}
+
+	if op == "!=" {
+		c.Predicate = func(column string, operand interface{}) squirrel.Sqlizer {
+			return squirrel.NotEq{column: operand}
+		}
+		c.Check = func(operand, received interface{}) error {
+			// NULL is not matched, as with SQL predicate.
+			if plainValue(received) == nil {
+				return fmt.Errorf("%w: NULL received", errConditionFailed)
+			}
+
+			if cmp, err := compareValues(received, operand); err == nil && cmp != 0 {
+				return nil
+			}
+
+			if !valuesEqual(plainValue(operand), plainValue(received)) {
+				return nil
+			}
+
+			return fmt.Errorf("%w: %v received", errConditionFailed, plainValue(received))
+		}
+
+		return c, true
+	}
+
+	c.Predicate = func(column string, operand interface{}) squirrel.Sqlizer {
+		switch op {
+		case ">":
+			return squirrel.Gt{column: operand}
+		case ">=":
+			return squirrel.GtOrEq{column: operand}
+		case "<":
+			return squirrel.Lt{column: operand}
+		default:
+			return squirrel.LtOrEq{column: operand}
+		}
+	}
+	c.Check = func(operand, received interface{}) error {
+		cmp, err := compareValues(received, operand)
+		if err != nil {
+			return err
+		}
+
+		passed := false
+
+		switch op {
+		case ">":
+			passed = cmp > 0
+		case ">=":
+			passed = cmp >= 0
+		case "<":
+			passed = cmp < 0
+		default:
+			passed = cmp <= 0
+		}
+
+		if !passed {
+			return fmt.Errorf("%w: %v %s %v is false", errConditionFailed, plainValue(received), op, plainValue(operand))
+		}
+
+		return nil
+	}
+
+	return c, true
+}
+
+func matchTolerance(value string) (Condition, bool) {
+	if !strings.HasPrefix(value, "~") {
+		return Condition{}, false
+	}
+
+	sep := "±"
+
+	pos := strings.LastIndex(value, sep)
+	if pos == -1 {
+		sep = "+-"
+		pos = strings.LastIndex(value, sep)
+	}
+
+	if pos == -1 {
+		return Condition{}, false
+	}
+
+	tolerance, err := time.ParseDuration(value[pos+len(sep):])
+
+	bounds := func(operand interface{}) (time.Time, time.Time, error) {
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid time tolerance: %w", err)
+		}
+
+		t, ok := plainValue(operand).(time.Time)
+		if !ok {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: time expected, %T received", errNotComparable, operand)
+		}
+
+		return t.Add(-tolerance), t.Add(tolerance), nil
+	}
+
+	return Condition{
+		Operand: value[1:pos],
+		Predicate: func(column string, operand interface{}) squirrel.Sqlizer {
+			from, to, err := bounds(operand)
+			if err != nil {
+				// Invalid tolerance is reported by Check.
+				return nil
+			}
+
+			return squirrel.And{squirrel.GtOrEq{column: from}, squirrel.LtOrEq{column: to}}
+		},
+		Check: func(operand, received interface{}) error {
+			from, to, err := bounds(operand)
+			if err != nil {
+				return err
+			}
+
+			t, ok := plainValue(received).(time.Time)
+			if !ok {
+				return fmt.Errorf("%w: time expected, %T received", errNotComparable, received)
+			}
+
+			if t.Before(from) || t.After(to) {
+				return fmt.Errorf("%w: %s is not within %s and %s", errConditionFailed,
+					t.Format(time.RFC3339Nano), from.Format(time.RFC3339Nano), to.Format(time.RFC3339Nano))
+			}
+
+			return nil
+		},
+	}, true
+}
+
+// plainValue dereferences pointers and unwraps values of driver.Valuer.
+func plainValue(v interface{}) interface{} {
+	v = indirect(v)
+
+	if vv, ok := v.(driver.Valuer); ok {
+		dv, err := vv.Value()
+		if err == nil {
+			return dv
+		}
+	}
+
+	return v
+}
+
+// compareValues returns -1, 0 or 1 if a is less than, equal to or greater than b.
+func compareValues(a, b interface{}) (int, error) {
+	a, b = plainValue(a), plainValue(b)
+
+	if a == nil || b == nil {
+		return 0, fmt.Errorf("%w: %v, %v", errNotComparable, a, b)
+	}
+
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			switch {
+			case ta.Before(tb):
+				return -1, nil
+			case ta.After(tb):
+				return 1, nil
+			default:
+				return 0, nil
+			}
+		}
+	}
+
+	if sa, ok := a.(string); ok {
+		if sb, ok := b.(string); ok {
+			return strings.Compare(sa, sb), nil
+		}
+	}
+
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+
+	if !okA || !okB {
+		return 0, fmt.Errorf("%w: %T, %T", errNotComparable, a, b)
+	}
+
+	switch {
+	case fa < fb:
+		return -1, nil
+	case fa > fb:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() { // nolint:exhaustive // Only numbers are handled.
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// resolvedCondition is a condition with decoded operand.
+type resolvedCondition struct {
+	Condition
+	operand interface{}
+}
+
+// resolveConditions decodes operands of conditions collected for current row.
+func (t *tableQuery) resolveConditions() (map[string]resolvedCondition, error) {
+	if len(t.conditions) == 0 {
+		return nil, nil
+	}
+
+	conditions := t.conditions
+	t.conditions = nil
+
+	res := make(map[string]resolvedCondition, len(conditions))
+
+	for col, c := range conditions {
+		rc := resolvedCondition{Condition: c}
+
+		switch {
+		case c.Operand == "":
+		case t.vars.IsVar(c.Operand):
+			v, found := t.vars.Get(c.Operand)
+			if !found {
+				return nil, fmt.Errorf("%w %s in column %s", errUnknownVariable, c.Operand, col)
+			}
+
+			rc.operand = v
+		default:
+			v, err := t.decodeCell(col, c.Operand)
+			if err != nil {
+				return nil, err
+			}
+
+			rc.operand = v
+		}
+
+		res[col] = rc
+	}
+
+	return res, nil
+}
+
+// decodeCell decodes a single cell value into Go value of column.
+func (t *tableQuery) decodeCell(column, value string) (interface{}, error) {
+	it, err := itemType(t.row)
+	if err != nil {
+		return nil, err
+	}
+
+	item := reflect.New(it)
+
+	if value != null {
+		if err := t.mapper.Decoder.Decode(item.Interface(), map[string][]string{column: {value}}); err != nil {
+			return nil, fmt.Errorf("failed to decode %q for column %s: %w", value, column, err)
+		}
+	}
+
+	_, vals := t.storage.Mapper.ColumnsValues(item, sqluct.Columns(column))
+	if len(vals) == 0 {
+		return nil, fmt.Errorf("%w %s in table %s", errUnknownColumn, column, t.table)
+	}
+
+	return vals[0], nil
+}
+
+// checkConditions asserts received column values with Go-side checks of conditions.
+func (t *tableQuery) checkConditions(conditions map[string]resolvedCondition, argsRcv map[string]interface{}) error {
+	for _, col := range t.colNames {
+		c, ok := conditions[col]
+		if !ok || c.Check == nil {
+			continue
+		}
+
+		if err := c.Check(c.operand, argsRcv[col]); err != nil {
+			return fmt.Errorf("column %s: %w", col, err)
+		}
+	}
+
+	return nil
+}
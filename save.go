@@ -0,0 +1,124 @@
+package dbdog
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+func (m *Manager) rowsOfTableOfDatabaseAreSavedToFile(ctx context.Context, tableName, dbName, filePath string) error {
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
+	filePath, err := m.writablePath(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	if format := fileFormat(filePath); format != formatCSV {
+		return fmt.Errorf("%w %s, only CSV files can be saved", errUnsupportedFormat, format)
+	}
+
+	t, err := m.makeTableQuery(ctx, tableName, dbName, nil)
+	if err != nil {
+		return err
+	}
+
+	// Rows are ordered by all columns, so that file contents is stable.
+	cols, _ := t.storage.Mapper.ColumnsValues(reflect.ValueOf(t.row))
+
+	return t.saveRows(t.storage.SelectStmt(t.table, t.row).OrderBy(cols...), filePath, nil)
+}
+
+// saveRows writes result of query to CSV file that can be used as rows file.
+//
+// Values are encoded with TableMapper, nulls are written as NULL. Values that would be taken as variables
+// or matchers are written with "::string" suffix. Header is made of names of columns
+// received from database if it is not provided. Permissions of existing file are kept.
+func (t *tableQuery) saveRows(qb squirrel.SelectBuilder, filePath string, header []string) (err error) {
+	rows, err := t.storage.Query(t.ctx, qb)
+	if err != nil {
+		return statementError(qb, err)
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	if header == nil {
+		header = cols
+	}
+
+	buf := bytes.NewBuffer(nil)
+	w := csv.NewWriter(buf)
+
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	var (
+		width  = make(map[string]int, len(cols))
+		res    = make(map[string][]string, len(cols))
+		record = make([]string, len(cols))
+	)
+
+	for rows.Next() {
+		if err := t.formatRow(rows, cols, width, res); err != nil {
+			return err
+		}
+
+		for i, col := range cols {
+			record[i] = t.escapeCell(res[col][0])
+			res[col] = res[col][:0]
+		}
+
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	perm := os.FileMode(0o600)
+
+	fi, err := os.Stat(filePath)
+	if err == nil {
+		perm = fi.Mode()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return ioutil.WriteFile(filePath, buf.Bytes(), perm)
+}
+
+// escapeCell adds string suffix to value that would otherwise be taken as a variable or a matcher.
+func (t *tableQuery) escapeCell(value string) string {
+	if _, ok := t.mapper.match(value); ok || t.vars.IsVar(value) || strings.HasSuffix(value, stringSuffix) {
+		return value + stringSuffix
+	}
+
+	return value
+}
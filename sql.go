@@ -0,0 +1,463 @@
+package dbdog
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/bool64/sqluct"
+)
+
+func (m *Manager) iExecuteThisSQLInDatabase(ctx context.Context, dbName string, query string) error {
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
+	instance, ok := m.Instances[dbName]
+	if !ok {
+		return fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+	}
+
+	m.checkInit()
+
+	query, err := m.interpolateVars(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range splitStatements(query) {
+		_, err := instance.Storage.Exec(
+			m.instanceContext(ctx, dbName),
+			sqluct.StringStatement(statement),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to execute statement %q in db %s: %w", statement, dbName, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) theSQLFileIsAppliedToDatabase(ctx context.Context, filePath, dbName string) error {
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
+	instance, ok := m.Instances[dbName]
+	if !ok {
+		return fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+	}
+
+	m.checkInit()
+
+	filePath, err := m.resolvePath(ctx, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to load SQL file: %w", err)
+	}
+
+	content, err := m.readResolved(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to load SQL file %s: %w", filePath, err)
+	}
+
+	query, err := m.interpolateVars(ctx, string(content))
+	if err != nil {
+		return err
+	}
+
+	return instance.Storage.InTx(m.instanceContext(ctx, dbName), func(txCtx context.Context) error {
+		for _, statement := range splitStatements(query) {
+			_, err := instance.Storage.Exec(txCtx, sqluct.StringStatement(statement))
+			if err != nil {
+				return fmt.Errorf("failed to execute statement %q of file %s in db %s: %w",
+					statement, filePath, dbName, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// interpolateVars replaces known variables in SQL text with their values as SQL literals.
+//
+// Quoted strings, dollar-quoted bodies and comments are kept as is.
+func (m *Manager) interpolateVars(ctx context.Context, query string) (string, error) {
+	var (
+		sb  strings.Builder
+		err error
+	)
+
+	scanSQL(query, func(kind sqlSegment, segment string) {
+		if kind == sqlCode && err == nil {
+			segment, err = m.replaceVars(ctx, segment, m.sqlLiteral)
+		}
+
+		sb.WriteString(segment)
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+// replaceVars replaces known variables in s with values formatted by format.
+//
+// Variable name is a whole token of prefix followed by letters, digits and underscores,
+// so that $id is not replaced in $id_other.
+func (m *Manager) replaceVars(ctx context.Context, s string, format func(v interface{}) (string, error)) (string, error) {
+	st, err := m.state(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	vars := st.vars
+
+	prefix := vars.VarPrefix
+	if prefix == "" {
+		prefix = "$"
+	}
+
+	if !strings.Contains(s, prefix) {
+		return s, nil
+	}
+
+	var sb strings.Builder
+
+	for i := 0; i < len(s); {
+		if !strings.HasPrefix(s[i:], prefix) {
+			sb.WriteByte(s[i])
+			i++
+
+			continue
+		}
+
+		end := i + len(prefix)
+		for end < len(s) && isIdentifierChar(s[end]) {
+			end++
+		}
+
+		name := s[i:end]
+
+		v, found := vars.Get(name)
+		if !found || end == i+len(prefix) {
+			sb.WriteString(name)
+			i = end
+
+			continue
+		}
+
+		value, err := format(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to interpolate variable %s: %w", name, err)
+		}
+
+		sb.WriteString(value)
+		i = end
+	}
+
+	return sb.String(), nil
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// sqlLiteral formats Go value as SQL literal, numbers and booleans are not quoted.
+func (m *Manager) sqlLiteral(v interface{}) (string, error) {
+	v = plainValue(v)
+	if v == nil {
+		return null, nil
+	}
+
+	if t, ok := v.(time.Time); ok {
+		return "'" + t.Format(time.RFC3339Nano) + "'", nil
+	}
+
+	s, err := m.TableMapper.Encode(v)
+	if err != nil {
+		return "", err
+	}
+
+	switch reflect.ValueOf(v).Kind() { // nolint:exhaustive // Other kinds are quoted.
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Bool:
+		return s, nil
+	default:
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'", nil
+	}
+}
+
+// splitStatements splits SQL text into statements separated with semicolons.
+//
+// Semicolons in quoted strings, dollar-quoted bodies and comments are ignored, comments are removed.
+func splitStatements(query string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+
+	scanSQL(query, func(kind sqlSegment, segment string) {
+		switch kind {
+		case sqlComment:
+			current.WriteString(" ")
+		case sqlSeparator:
+			statements = appendStatement(statements, current.String())
+			current.Reset()
+		default:
+			current.WriteString(segment)
+		}
+	})
+
+	return appendStatement(statements, current.String())
+}
+
+// sqlSegment is a kind of part of SQL text.
+type sqlSegment int
+
+const (
+	sqlCode sqlSegment = iota
+	sqlQuoted
+	sqlComment
+	sqlSeparator
+)
+
+// scanSQL calls fn with consecutive parts of SQL text: code, quoted strings and dollar-quoted bodies,
+// comments and semicolons that separate statements.
+func scanSQL(query string, fn func(kind sqlSegment, segment string)) {
+	start := 0
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		kind, end := sqlCode, i+1
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// Escaped quotes are doubled, so the quote is closed and immediately reopened.
+			kind, end = sqlQuoted, closingIndex(query, i+1, string(c))
+		case strings.HasPrefix(query[i:], "--"):
+			kind, end = sqlComment, closingIndex(query, i+2, "\n")
+		case strings.HasPrefix(query[i:], "/*"):
+			kind, end = sqlComment, closingIndex(query, i+2, "*/")
+		case c == '$':
+			if tag := dollarTag(query[i:]); tag != "" {
+				kind, end = sqlQuoted, closingIndex(query, i+len(tag), tag)
+			}
+		case c == ';':
+			kind = sqlSeparator
+		}
+
+		if kind != sqlCode {
+			if i > start {
+				fn(sqlCode, query[start:i])
+			}
+
+			fn(kind, query[i:end])
+			start = end
+		}
+
+		i = end
+	}
+
+	if start < len(query) {
+		fn(sqlCode, query[start:])
+	}
+}
+
+// closingIndex returns position after the first occurrence of closing sequence starting from offset,
+// or length of query if closing sequence is missing.
+func closingIndex(query string, offset int, closing string) int {
+	if offset > len(query) {
+		return len(query)
+	}
+
+	pos := strings.Index(query[offset:], closing)
+	if pos == -1 {
+		return len(query)
+	}
+
+	return offset + pos + len(closing)
+}
+
+// dollarTag returns opening tag of dollar-quoted string, e.g. "$$" or "$body$", or empty string.
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+
+		if c == '$' {
+			return s[:i+1]
+		}
+
+		// Tag follows rules of unquoted identifier, so that positional parameters like $1 are not tags.
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9' || i == 1) {
+			return ""
+		}
+	}
+
+	return ""
+}
+
+func appendStatement(statements []string, statement string) []string {
+	statement = strings.TrimSpace(statement)
+	if statement == "" {
+		return statements
+	}
+
+	return append(statements, statement)
+}
+
+// queryResult keeps rows received with raw SQL query, values are encoded with TableMapper.
+type queryResult struct {
+	dbName  string
+	columns []string
+	values  map[string][]string
+	width   map[string]int
+	count   int
+}
+
+func (r *queryResult) render() string {
+	t := tableQuery{}
+
+	return t.renderRows(r.columns, r.values, r.width, r.count)
+}
+
+func (m *Manager) iQueryDatabaseWithThisSQL(ctx context.Context, dbName string, query string) (err error) {
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
+	instance, ok := m.Instances[dbName]
+	if !ok {
+		return fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+	}
+
+	m.checkInit()
+
+	st, err := m.state(ctx)
+	if err != nil {
+		return err
+	}
+
+	st.lastQuery = nil
+
+	query, err = m.interpolateVars(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	rows, err := instance.Storage.Query(m.instanceContext(ctx, dbName), sqluct.StringStatement(query))
+	if err != nil {
+		return fmt.Errorf("failed to execute query %q in db %s: %w", query, dbName, err)
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	res := queryResult{
+		dbName:  dbName,
+		columns: cols,
+		values:  make(map[string][]string, len(cols)),
+		width:   make(map[string]int, len(cols)),
+	}
+
+	for _, col := range cols {
+		res.width[col] = len(col)
+		res.values[col] = []string{}
+	}
+
+	t := tableQuery{mapper: m.TableMapper}
+
+	for rows.Next() {
+		res.count++
+
+		if err := t.formatRow(rows, cols, res.width, res.values); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	st.lastQuery = &res
+
+	return nil
+}
+
+// theQueryResultMatches compares encoded values of last query result with gherkin table.
+//
+// Query result has no Go types of columns, so matchers are not supported and cells are compared
+// as literal values. Variables are captured with encoded string values.
+func (m *Manager) theQueryResultMatches(ctx context.Context, data [][]string) (err error) {
+	st, err := m.state(ctx)
+	if err != nil {
+		return err
+	}
+
+	vars := st.vars
+
+	res := st.lastQuery
+	if res == nil {
+		return errNoQueryResult
+	}
+
+	defer func() {
+		// Expose query result to simplify test debugging.
+		if err != nil {
+			err = fmt.Errorf("%w, query result in db %s:\n%v", err, res.dbName, res.render())
+		}
+	}()
+
+	if len(data) == 0 {
+		return errRowRequired
+	}
+
+	for _, col := range data[0] {
+		if _, ok := res.values[col]; !ok {
+			return fmt.Errorf("%w %s in query result", errUnknownColumn, col)
+		}
+	}
+
+	if len(data)-1 != res.count {
+		return fmt.Errorf("%w: %d expected, %d found",
+			errInvalidNumberOfRows, len(data)-1, res.count)
+	}
+
+	for i, row := range data[1:] {
+		for j, exp := range row {
+			col := data[0][j]
+			rcv := res.values[col][i]
+
+			if isVar(vars, exp) {
+				v, found := vars.Get(exp)
+				if !found {
+					vars.Set(exp, rcv)
+
+					continue
+				}
+
+				if exp, err = m.TableMapper.Encode(v); err != nil {
+					return err
+				}
+			}
+
+			exp = strings.TrimSuffix(exp, stringSuffix)
+
+			if exp != rcv {
+				return fmt.Errorf("%w at row %d, column %s (%q expected, %q received)",
+					errRowMismatch, i, col, exp, rcv)
+			}
+		}
+	}
+
+	return nil
+}
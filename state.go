@@ -0,0 +1,50 @@
+package dbdog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/bool64/shared"
+	"github.com/jmoiron/sqlx"
+)
+
+type scenarioStateKey struct{}
+
+var errNoScenarioState = errors.New("no scenario state in context, steps must be registered with Manager.RegisterSteps")
+
+// scenarioState keeps data of a single scenario, it is stored in scenario context,
+// so that Manager is not modified by steps and scenarios can run concurrently.
+type scenarioState struct {
+	vars      *shared.Vars
+	lastQuery *queryResult
+	tx        map[string]*sqlx.Tx
+	conns     map[string]*sql.Conn
+}
+
+// newScenarioState creates state with own vars, that are seeded with Manager.Vars if it is configured.
+func (m *Manager) newScenarioState() *scenarioState {
+	vars := &shared.Vars{}
+
+	if m.Vars != nil {
+		vars.VarPrefix = m.Vars.VarPrefix
+
+		for k, v := range m.Vars.GetAll() {
+			vars.Set(k, v)
+		}
+	}
+
+	return &scenarioState{vars: vars}
+}
+
+// state returns scenario state from context.
+//
+// State is added to context before each scenario by RegisterSteps, missing state is an error,
+// so that variables and query results of a scenario are not lost with a fresh state.
+func (m *Manager) state(ctx context.Context) (*scenarioState, error) {
+	if st, ok := ctx.Value(scenarioStateKey{}).(*scenarioState); ok {
+		return st, nil
+	}
+
+	return nil, errNoScenarioState
+}
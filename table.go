@@ -6,15 +6,30 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/bool64/shared"
 	"github.com/swaggest/form/v5"
 )
 
-const null = "NULL"
+const (
+	null = "NULL"
+
+	// stringSuffix marks cell value as a plain string, that is not a matcher, JSON or variable.
+	stringSuffix = "::string"
+)
+
+// isVar checks if cell value is a variable name, values with stringSuffix are not variables.
+func isVar(vars *shared.Vars, value string) bool {
+	return vars.IsVar(value) && !strings.HasSuffix(value, stringSuffix)
+}
 
 // TableMapper maps data from Go value to string and back.
 type TableMapper struct {
 	Decoder *form.Decoder
 	Encoder *form.Encoder
+
+	// Matchers are checked in order to find a custom condition for a cell value of assertion table.
+	// Custom matchers can be added to this list, NewTableMapper populates it with DefaultMatchers.
+	Matchers []Matcher
 }
 
 func isNil(v interface{}) bool {
@@ -134,9 +149,9 @@ func (m *TableMapper) IterateTable(c IterateConfig) error {
 				continue
 			}
 
-			cell = strings.TrimSuffix(cell, "::string")
-
-			if v, found := c.Replaces[cell]; found {
+			if strings.HasSuffix(cell, stringSuffix) {
+				cell = strings.TrimSuffix(cell, stringSuffix)
+			} else if v, found := c.Replaces[cell]; found {
 				cell = v
 			}
 
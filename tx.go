@@ -0,0 +1,126 @@
+package dbdog
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/bool64/sqluct"
+	"github.com/jmoiron/sqlx"
+)
+
+type txKey string
+
+// TxFromContext returns transaction of database instance started for the scenario
+// when Manager.TransactionPerScenario is enabled.
+//
+// Transaction of DefaultDatabase or of the only configured instance is also available with sqluct.TxFromContext,
+// so that sqluct.Storage of application joins it automatically.
+func TxFromContext(ctx context.Context, dbName string) *sqlx.Tx {
+	tx, _ := ctx.Value(txKey(dbName)).(*sqlx.Tx)
+
+	return tx
+}
+
+// beginScenarioTx starts transactions in all database instances.
+func (m *Manager) beginScenarioTx(ctx context.Context) (context.Context, error) {
+	dbNames := make([]string, 0, len(m.Instances))
+	for dbName := range m.Instances {
+		dbNames = append(dbNames, dbName)
+	}
+
+	sort.Strings(dbNames)
+
+	st, err := m.state(ctx)
+	if err != nil {
+		return ctx, err
+	}
+
+	st.tx = make(map[string]*sqlx.Tx, len(dbNames))
+	st.conns = make(map[string]*sql.Conn, len(dbNames))
+
+	for _, dbName := range dbNames {
+		// Transaction is bound to a connection, so that raw connection is available for COPY.
+		conn, err := m.Instances[dbName].Storage.DB().Connx(ctx)
+		if err != nil {
+			if rbErr := m.rollbackScenarioTx(ctx); rbErr != nil {
+				err = fmt.Errorf("%w, %v", err, rbErr)
+			}
+
+			return ctx, fmt.Errorf("failed to get connection of db %s: %w", dbName, err)
+		}
+
+		st.conns[dbName] = conn.Conn
+
+		tx, err := conn.BeginTxx(ctx, nil)
+		if err != nil {
+			if rbErr := m.rollbackScenarioTx(ctx); rbErr != nil {
+				err = fmt.Errorf("%w, %v", err, rbErr)
+			}
+
+			return ctx, fmt.Errorf("failed to begin transaction in db %s: %w", dbName, err)
+		}
+
+		st.tx[dbName] = tx
+		ctx = context.WithValue(ctx, txKey(dbName), tx)
+
+		if dbName == DefaultDatabase || len(dbNames) == 1 {
+			ctx = context.WithValue(sqluct.TxToContext(ctx, tx), connKey{}, conn.Conn)
+		}
+	}
+
+	return ctx, nil
+}
+
+// rollbackScenarioTx rolls back transactions started for the scenario.
+func (m *Manager) rollbackScenarioTx(ctx context.Context) error {
+	// Without scenario state, for example if Before hook has not run, there are no transactions to roll back.
+	st, err := m.state(ctx)
+	if err != nil {
+		return nil // nolint:nilerr // Missing state is not an error here.
+	}
+
+	for dbName, tx := range st.tx {
+		if rbErr := tx.Rollback(); rbErr != nil && err == nil {
+			err = fmt.Errorf("failed to rollback transaction in db %s: %w", dbName, rbErr)
+		}
+	}
+
+	for dbName, conn := range st.conns {
+		if clErr := conn.Close(); clErr != nil && err == nil {
+			err = fmt.Errorf("failed to close connection of db %s: %w", dbName, clErr)
+		}
+	}
+
+	st.tx = nil
+	st.conns = nil
+
+	return err
+}
+
+// instanceContext returns context to run statements in database instance.
+//
+// Context is derived from step context and carries transaction of database instance if it is available.
+func (m *Manager) instanceContext(ctx context.Context, dbName string) context.Context {
+	// Without scenario state there are no scenario transactions.
+	st, err := m.state(ctx)
+	if err != nil {
+		return ctx
+	}
+
+	if tx := st.tx[dbName]; tx != nil {
+		return context.WithValue(sqluct.TxToContext(ctx, tx), connKey{}, st.conns[dbName])
+	}
+
+	return ctx
+}
+
+// stepContext limits step context with Manager.StepTimeout.
+func (m *Manager) stepContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.StepTimeout == 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, m.StepTimeout)
+}
@@ -0,0 +1,94 @@
+package dbdog
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// UpdateFilesEnv is a name of environment variable that enables Manager.UpdateFiles with any non-empty value.
+const UpdateFilesEnv = "DBDOG_UPDATE_FILES"
+
+func (m *Manager) updateFiles() bool {
+	return m.UpdateFiles || os.Getenv(UpdateFilesEnv) != ""
+}
+
+// withFile opens file with rows and closes it after fn.
+func (m *Manager) withFile(ctx context.Context, filePath string, fn func(tf *tableFile) error) (err error) {
+	tf, err := m.openFile(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	defer closeFile(tf, &err)
+
+	return fn(tf)
+}
+
+// assertFile asserts rows of file and, in update mode, rewrites file with table rows if exhaustive assertion fails.
+//
+// Files of non-exhaustive assertions are not updated, as they may intentionally contain only some of table rows.
+func (m *Manager) assertFile(
+	ctx context.Context, tableName, dbName, filePath, orderBy string, exhaustiveList bool,
+	assertRows func(tf *tableFile) error,
+) error {
+	var header []string
+
+	err := m.withFile(ctx, filePath, func(tf *tableFile) error {
+		header = tf.header
+		filePath = tf.path
+
+		return assertRows(tf)
+	})
+
+	if err == nil || header == nil || !m.updateFiles() {
+		return err
+	}
+
+	if !exhaustiveList {
+		return fmt.Errorf("%w, file %s is not updated for non-exhaustive assertion", err, filePath)
+	}
+
+	if updErr := m.updateFile(ctx, tableName, dbName, filePath, header, orderBy); updErr != nil {
+		return fmt.Errorf("%w, failed to update file %s: %v", err, filePath, updErr)
+	}
+
+	log.Printf("dbdog: file %s is updated with rows of table %s in database %s after failed assertion: %s",
+		filePath, tableName, dbName, strings.SplitN(err.Error(), "\n", 2)[0])
+
+	return nil
+}
+
+// updateFile writes table rows to CSV file with resolved path, columns are taken from file header.
+func (m *Manager) updateFile(ctx context.Context, tableName, dbName, filePath string, header []string, orderBy string) error {
+	if m.FixturesFS != nil {
+		return errReadOnlyFS
+	}
+
+	if format := fileFormat(filePath); format != formatCSV {
+		return fmt.Errorf("%w %s, only CSV files can be updated", errUnsupportedFormat, format)
+	}
+
+	ctx, cancel := m.stepContext(ctx)
+	defer cancel()
+
+	t, err := m.makeTableQuery(ctx, tableName, dbName, [][]string{header})
+	if err != nil {
+		return err
+	}
+
+	qb := t.storage.QueryBuilder().
+		Select(header...).
+		From(t.table)
+
+	// Rows are ordered by all columns of file if order is not specified, so that file contents is stable.
+	if orderBy != "" {
+		qb = qb.OrderBy(orderBy)
+	} else {
+		qb = qb.OrderBy(header...)
+	}
+
+	return t.saveRows(qb, filePath, header)
+}